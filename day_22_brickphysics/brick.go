@@ -0,0 +1,177 @@
+// Package brickphysics holds the brick model, parsing and settling shared by both day 22 puzzles.
+package brickphysics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Coordinate is a cell in 3D space, Z points up.
+type Coordinate struct {
+	X int
+	Y int
+	Z int
+}
+
+// Point is a cell of the XY plane.
+type Point struct {
+	X int
+	Y int
+}
+
+// Brick holds its coordinates by value, so copying a brick never shares its position with the original.
+// A brick is either the box from Start to End, or a polycube: the connected cells at Start plus each of the Offsets.
+// For a polycube Start and End are the corners of its bounding box. Offsets are never changed in place,
+// so copies of a brick can safely share them.
+type Brick struct {
+	Id      int
+	Line    int // The line of the input the brick was read from.
+	Start   Coordinate
+	End     Coordinate
+	Offsets []Coordinate // The cells of a polycube relative to Start, nil for a box.
+}
+
+// ToString formats the brick the way it appears in the input.
+func (this *Brick) ToString() string {
+	if this.IsBox() {
+		return fmt.Sprintf("%d,%d,%d~%d,%d,%d", this.Start.X, this.Start.Y, this.Start.Z, this.End.X, this.End.Y, this.End.Z)
+	}
+
+	offsets := make([]string, len(this.Offsets))
+	for i, offset := range this.Offsets {
+		offsets[i] = fmt.Sprintf("%d,%d,%d", offset.X, offset.Y, offset.Z)
+	}
+	return fmt.Sprintf("%d,%d,%d@%s", this.Start.X, this.Start.Y, this.Start.Z, strings.Join(offsets, ";"))
+}
+
+// IsBox checks if the brick is a box rather than a polycube.
+func (this *Brick) IsBox() bool {
+	return this.Offsets == nil
+}
+
+// Voxels returns every cell the brick occupies.
+func (this *Brick) Voxels() []Coordinate {
+	var voxels []Coordinate
+
+	if this.IsBox() {
+		for x := this.Start.X; x <= this.End.X; x++ {
+			for y := this.Start.Y; y <= this.End.Y; y++ {
+				for z := this.Start.Z; z <= this.End.Z; z++ {
+					voxels = append(voxels, Coordinate{X: x, Y: y, Z: z})
+				}
+			}
+		}
+		return voxels
+	}
+
+	for _, offset := range this.Offsets {
+		voxels = append(voxels, Coordinate{X: this.Start.X + offset.X, Y: this.Start.Y + offset.Y, Z: this.Start.Z + offset.Z})
+	}
+	return voxels
+}
+
+// Volume returns the number of cells the brick occupies.
+func (this *Brick) Volume() int {
+	if this.IsBox() {
+		return (this.End.X - this.Start.X + 1) * (this.End.Y - this.Start.Y + 1) * (this.End.Z - this.Start.Z + 1)
+	}
+	return len(this.Offsets)
+}
+
+// boxes splits the brick in boxes given as their two corners, a single one for a box and one per cell for a polycube.
+func (this *Brick) boxes() [][2]Coordinate {
+	if this.IsBox() {
+		return [][2]Coordinate{{this.Start, this.End}}
+	}
+
+	boxes := make([][2]Coordinate, len(this.Offsets))
+	for i, voxel := range this.Voxels() {
+		boxes[i] = [2]Coordinate{voxel, voxel}
+	}
+	return boxes
+}
+
+// MoveDown lowers the brick by a single level.
+func (this *Brick) MoveDown() {
+	this.Start.Z--
+	this.End.Z--
+}
+
+// GetCoveredPoints returns the (x, y) points the brick covers when seen from above.
+func (this *Brick) GetCoveredPoints() []Point {
+	var points []Point
+
+	if this.IsBox() {
+		for x := this.Start.X; x <= this.End.X; x++ {
+			for y := this.Start.Y; y <= this.End.Y; y++ {
+				points = append(points, Point{X: x, Y: y})
+			}
+		}
+		return points
+	}
+
+	seen := make(map[Point]bool)
+	for _, voxel := range this.Voxels() {
+		point := Point{X: voxel.X, Y: voxel.Y}
+		if !seen[point] {
+			seen[point] = true
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// IsSupportedBy checks if this brick rests directly on the other brick.
+func (this *Brick) IsSupportedBy(other *Brick) bool {
+	if this.IsBox() && other.IsBox() {
+		// Check if the upper brick is directly above the lower brick in the Z dimension
+		directlyAbove := this.Start.Z-1 == other.End.Z
+
+		if directlyAbove {
+			for _, thisPoint := range this.GetCoveredPoints() {
+				for _, otherPoint := range other.GetCoveredPoints() {
+					if thisPoint.X == otherPoint.X && thisPoint.Y == otherPoint.Y {
+						return true
+					}
+				}
+			}
+		}
+
+		return false
+	}
+
+	// Any cell of this brick sitting directly on a cell of the other brick supports it.
+	otherVoxels := make(map[Coordinate]bool)
+	for _, voxel := range other.Voxels() {
+		otherVoxels[voxel] = true
+	}
+	for _, voxel := range this.Voxels() {
+		if otherVoxels[Coordinate{X: voxel.X, Y: voxel.Y, Z: voxel.Z - 1}] {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot returns an independent copy of a stack, moving bricks in the copy never moves them in the original.
+func Snapshot(bricks []Brick) []Brick {
+	bricksCopy := make([]Brick, len(bricks))
+	copy(bricksCopy, bricks)
+	return bricksCopy
+}
+
+// SnapshotWithout returns an independent copy of a stack with the bricks with the given ids left out.
+func SnapshotWithout(bricks []Brick, ids ...int) []Brick {
+	removed := make(map[int]bool)
+	for _, id := range ids {
+		removed[id] = true
+	}
+
+	bricksCopy := make([]Brick, 0, len(bricks))
+	for _, brick := range bricks {
+		if !removed[brick.Id] {
+			bricksCopy = append(bricksCopy, brick)
+		}
+	}
+	return bricksCopy
+}
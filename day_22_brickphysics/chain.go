@@ -0,0 +1,84 @@
+package brickphysics
+
+// The id used for the floor in the dominator tree, brick ids start at 1.
+const FloorId = 0
+
+// ChainReactions holds the dominator tree of the support graph, rooted at the floor.
+// Brick B falls when brick A is removed exactly when A dominates B: every path of
+// supports from the floor up to B passes through A.
+type ChainReactions struct {
+	immediateDominators map[int]int
+	fallCounts          map[int]int
+}
+
+// ComputeChainReactions builds the dominator tree of the settled bricks in a single pass.
+func ComputeChainReactions(bricks []Brick, graph *SupportGraph) *ChainReactions {
+	// Visit every brick after all of its supporters, whatever the direction they fell in.
+	order := graph.topologicalOrder(bricks)
+
+	chainReactions := &ChainReactions{immediateDominators: make(map[int]int), fallCounts: make(map[int]int)}
+	depths := map[int]int{FloorId: 0}
+
+	// The immediate dominator of a brick is the common ancestor of all its supporters.
+	// A brick that also rests on the floor has the floor among its supporters, making the floor its dominator.
+	for _, id := range order {
+		dominator := FloorId
+		for i, supporterID := range graph.SupportedBy(id) {
+			if i == 0 {
+				dominator = supporterID
+			} else {
+				dominator = chainReactions.commonDominator(dominator, supporterID, depths)
+			}
+		}
+		chainReactions.immediateDominators[id] = dominator
+		depths[id] = depths[dominator] + 1
+	}
+
+	// Walk top down so each subtree is complete before it is added to its dominator.
+	subtreeSizes := make(map[int]int)
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		subtreeSizes[id]++
+		chainReactions.fallCounts[id] = subtreeSizes[id] - 1
+		subtreeSizes[chainReactions.immediateDominators[id]] += subtreeSizes[id]
+	}
+
+	return chainReactions
+}
+
+// commonDominator walks two bricks up the dominator tree until they meet.
+func (this *ChainReactions) commonDominator(a, b int, depths map[int]int) int {
+	for a != b {
+		if depths[a] >= depths[b] {
+			a = this.immediateDominators[a]
+		} else {
+			b = this.immediateDominators[b]
+		}
+	}
+	return a
+}
+
+// ImmediateDominator returns the brick whose removal is the closest one to make the given brick fall,
+// or FloorId when only the floor holds it up.
+func (this *ChainReactions) ImmediateDominator(id int) int {
+	return this.immediateDominators[id]
+}
+
+// FallCount returns how many other bricks fall when the given brick is removed.
+func (this *ChainReactions) FallCount(id int) int {
+	return this.fallCounts[id]
+}
+
+// FallCounts returns the number of falling bricks for each removed brick id.
+func (this *ChainReactions) FallCounts() map[int]int {
+	return this.fallCounts
+}
+
+// Total returns the sum of the falling bricks over every single removal.
+func (this *ChainReactions) Total() int {
+	total := 0
+	for _, fallCount := range this.fallCounts {
+		total += fallCount
+	}
+	return total
+}
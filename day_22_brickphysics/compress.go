@@ -0,0 +1,124 @@
+package brickphysics
+
+import (
+	"sort"
+)
+
+// compression maps the coordinates of a set of bricks along some axes onto small consecutive numbers.
+// Every brick edge along a compressed axis becomes a boundary, and every range between two boundaries
+// becomes a single cell. Two bricks overlap after compression exactly when they did before, so a stack
+// with a huge but sparse footprint settles the same, using memory for its bricks rather than its area.
+type compression struct {
+	boundaries [3][]int // The sorted boundaries along X, Y and Z, nil for an axis that is left alone.
+}
+
+// Helper function to collect the boundaries of the bricks along X and Y, and along Z when asked for.
+func newCompression(bricks []Brick, compressZ bool) compression {
+	var edges [3]map[int]bool
+	axes := 2
+	if compressZ {
+		axes = 3
+	}
+	for axis := 0; axis < axes; axis++ {
+		edges[axis] = make(map[int]bool)
+	}
+
+	for i := range bricks {
+		for _, box := range bricks[i].boxes() {
+			for axis := 0; axis < axes; axis++ {
+				edges[axis][box[0].axis(axis)] = true
+				edges[axis][box[1].axis(axis)+1] = true
+			}
+		}
+	}
+
+	var result compression
+	for axis := 0; axis < axes; axis++ {
+		for edge := range edges[axis] {
+			result.boundaries[axis] = append(result.boundaries[axis], edge)
+		}
+		sort.Ints(result.boundaries[axis])
+	}
+	return result
+}
+
+// Helper function to add a boundary along a compressed axis, making the coordinate the start of a cell.
+func (this *compression) addBoundary(axis int, value int) {
+	boundaries := this.boundaries[axis]
+	i := sort.SearchInts(boundaries, value)
+	if i < len(boundaries) && boundaries[i] == value {
+		return
+	}
+	boundaries = append(boundaries, 0)
+	copy(boundaries[i+1:], boundaries[i:])
+	boundaries[i] = value
+	this.boundaries[axis] = boundaries
+}
+
+// Helper function to map the first cell of a range starting at the coordinate.
+func (this compression) compressStart(c Coordinate) Coordinate {
+	for axis, boundaries := range this.boundaries {
+		if boundaries != nil {
+			c = c.withAxis(axis, sort.SearchInts(boundaries, c.axis(axis)))
+		}
+	}
+	return c
+}
+
+// Helper function to map the last cell of a range ending at the coordinate.
+func (this compression) compressEnd(c Coordinate) Coordinate {
+	for axis, boundaries := range this.boundaries {
+		if boundaries != nil {
+			c = c.withAxis(axis, sort.SearchInts(boundaries, c.axis(axis)+1)-1)
+		}
+	}
+	return c
+}
+
+// expand maps a compressed cell back to the first coordinate of the range it stands for.
+func (this compression) expand(c Coordinate) Coordinate {
+	for axis, boundaries := range this.boundaries {
+		if boundaries != nil {
+			c = c.withAxis(axis, boundaries[c.axis(axis)])
+		}
+	}
+	return c
+}
+
+// compress maps the brick onto the compressed coordinates, the cells of a polycube one by one.
+func (this compression) compress(brick Brick) Brick {
+	if !brick.IsBox() {
+		voxels := brick.Voxels()
+		for i := range voxels {
+			voxels[i] = this.compressStart(voxels[i])
+		}
+		return brick.withVoxels(voxels)
+	}
+
+	brick.Start, brick.End = this.compressStart(brick.Start), this.compressEnd(brick.End)
+	return brick
+}
+
+// Helper function to get the coordinate along an axis, 0 for X, 1 for Y and 2 for Z.
+func (this Coordinate) axis(axis int) int {
+	switch axis {
+	case 0:
+		return this.X
+	case 1:
+		return this.Y
+	}
+	return this.Z
+}
+
+// Helper function to replace the coordinate along an axis, 0 for X, 1 for Y and 2 for Z.
+func (this Coordinate) withAxis(axis int, value int) Coordinate {
+	switch axis {
+	case 0:
+		this.X = value
+	case 1:
+		this.Y = value
+	default:
+		this.Z = value
+	}
+	return this
+}
@@ -0,0 +1,6 @@
+//go:build bricksdebug
+
+package brickphysics
+
+// Debug builds, made with -tags bricksdebug, check the invariants of every settled stack.
+const checkInvariants = true
@@ -0,0 +1,184 @@
+package brickphysics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The naive solvers below are the original step by step implementations, kept as the reference
+// the optimised Stack, SupportGraph and ChainReactions are checked against. They only know about
+// boxes falling along -Z onto DefaultFloor.
+
+// Helper function to let the bricks fall one level at a time, checking every settled brick at each level.
+// It returns the settled bricks in landing order and how many bricks moved.
+func naiveSimulateFall(bricks []Brick) ([]Brick, int) {
+	bricks = Snapshot(bricks)
+	sort.SliceStable(bricks, func(i, j int) bool {
+		return bricks[i].Start.Z < bricks[j].Start.Z
+	})
+
+	fallCount := 0
+	for i := range bricks {
+		moved := false
+		for bricks[i].Start.Z > DefaultFloor && !naiveIsSupported(bricks[i], bricks[:i]) {
+			bricks[i].MoveDown()
+			moved = true
+		}
+		if moved {
+			fallCount++
+		}
+	}
+
+	return bricks, fallCount
+}
+
+// Helper function to check if any of the other bricks supports the brick.
+func naiveIsSupported(brick Brick, others []Brick) bool {
+	for j := range others {
+		if brick.IsSupportedBy(&others[j]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to check if every brick resting on the brick at the index rests on another brick too.
+func naiveCanBeSafelyRemoved(settledBricks []Brick, index int) bool {
+	for upper := range settledBricks {
+		if upper == index || !settledBricks[upper].IsSupportedBy(&settledBricks[index]) {
+			continue
+		}
+
+		otherSupport := false
+		for lower := range settledBricks {
+			if lower != index && lower != upper && settledBricks[upper].IsSupportedBy(&settledBricks[lower]) {
+				otherSupport = true
+				break
+			}
+		}
+		if !otherSupport {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper function to settle the bricks with both the naive and the optimised solvers, and compare
+// where every brick lands, which bricks can be safely removed and how many bricks fall when each
+// brick is removed. It returns an error describing the first difference, nil when they agree.
+func compareSolvers(bricks []Brick) error {
+	naiveSettled, _ := naiveSimulateFall(bricks)
+
+	physics := NewPhysics()
+	settled, _, supporters, err := physics.SimulateFall(Snapshot(bricks))
+	if err != nil {
+		return err
+	}
+	graph := NewSupportGraph(supporters)
+	chainReactions := ComputeChainReactions(settled, graph)
+
+	positions := make(map[int]Brick)
+	for _, brick := range settled {
+		positions[brick.Id] = brick
+	}
+
+	// Compare in id order, so the same stack always reports the same difference.
+	sort.Slice(naiveSettled, func(i, j int) bool { return naiveSettled[i].Id < naiveSettled[j].Id })
+
+	for _, brick := range naiveSettled {
+		if other, found := positions[brick.Id]; !found || other.Start != brick.Start || other.End != brick.End {
+			return fmt.Errorf("brick %d settles at %s instead of %s", brick.Id, other.ToString(), brick.ToString())
+		}
+	}
+
+	for i, brick := range naiveSettled {
+		if safe, naiveSafe := CanBeSafelyRemoved(brick, graph), naiveCanBeSafelyRemoved(naiveSettled, i); safe != naiveSafe {
+			return fmt.Errorf("brick %d can be safely removed is %t instead of %t", brick.Id, safe, naiveSafe)
+		}
+	}
+
+	for _, brick := range naiveSettled {
+		_, naiveFallCount := naiveSimulateFall(SnapshotWithout(naiveSettled, brick.Id))
+		if fallCount := chainReactions.FallCount(brick.Id); fallCount != naiveFallCount {
+			return fmt.Errorf("removing brick %d makes %d bricks fall instead of %d", brick.Id, fallCount, naiveFallCount)
+		}
+	}
+
+	return nil
+}
+
+// Helper function to remove bricks from a failing stack for as long as it keeps failing.
+// It first tries to leave out large chunks of bricks, then smaller ones, and finally single
+// bricks until no brick can be left out anymore.
+func shrinkCounterExample(bricks []Brick, fails func([]Brick) bool) []Brick {
+	chunk := max(len(bricks)/2, 1)
+
+	for {
+		removed := false
+		for start := 0; start < len(bricks); {
+			end := min(start+chunk, len(bricks))
+			candidate := append(Snapshot(bricks[:start]), bricks[end:]...)
+
+			if fails(candidate) {
+				bricks = candidate
+				removed = true
+			} else {
+				start = end
+			}
+		}
+
+		if chunk > 1 {
+			chunk /= 2
+		} else if !removed {
+			return bricks
+		}
+	}
+}
+
+// Helper function to fail the test with the smallest part of the stack the solvers still disagree on.
+func checkSolversAgree(t *testing.T, config GeneratorConfig) {
+	t.Helper()
+
+	bricks, err := Generate(config)
+	if err != nil {
+		t.Fatalf("generating seed %d: %v", config.Seed, err)
+	}
+	if compareSolvers(bricks) == nil {
+		return
+	}
+
+	smallest := shrinkCounterExample(bricks, func(candidate []Brick) bool {
+		return compareSolvers(candidate) != nil
+	})
+	var input strings.Builder
+	WriteBricks(&input, smallest)
+	t.Fatalf("seed %d: %v\nminimal counter-example of %d bricks:\n%s", config.Seed, compareSolvers(smallest), len(smallest), input.String())
+}
+
+func TestDifferential(t *testing.T) {
+	config := GeneratorConfig{Width: 5, Depth: 5, MinZ: DefaultFloor, MaxZ: 80, Count: 60, MaxLength: 4, VerticalRatio: 0.2}
+	for seed := int64(1); seed <= 30; seed++ {
+		config.Seed = seed
+		checkSolversAgree(t, config)
+	}
+}
+
+func FuzzSolvers(f *testing.F) {
+	f.Add(int64(1), 5, 40, 0.2)
+	f.Add(int64(2), 3, 80, 0.5)
+	f.Add(int64(3), 10, 20, 0.0)
+
+	f.Fuzz(func(t *testing.T, seed int64, footprint int, count int, verticalRatio float64) {
+		if footprint < 1 || footprint > 10 || count < 0 || count > 80 || !(verticalRatio >= 0 && verticalRatio <= 1) {
+			t.Skip()
+		}
+
+		config := GeneratorConfig{Seed: seed, Width: footprint, Depth: footprint, MinZ: DefaultFloor, MaxZ: 2 * count, Count: count, MaxLength: 4, VerticalRatio: verticalRatio}
+		if _, err := Generate(config); err != nil {
+			t.Skip()
+		}
+		checkSolversAgree(t, config)
+	})
+}
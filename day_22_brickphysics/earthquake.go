@@ -0,0 +1,90 @@
+package brickphysics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// EarthquakeConfig describes a Monte Carlo run of SimulateEarthquakes.
+type EarthquakeConfig struct {
+	Seed    int64 // The seed picking the bricks, the same seed picks the same bricks.
+	Removed int   // The number of random bricks removed at once in every trial.
+	Trials  int
+	Workers int // The number of goroutines running the trials, zero or fewer uses one per CPU.
+}
+
+// Earthquakes holds the collapse sizes of a Monte Carlo run, the number of bricks that fell in every trial.
+type Earthquakes struct {
+	Collapses []int // In trial order.
+	sorted    []int
+}
+
+// HistogramBucket counts the trials with a collapse size between From and To, both included.
+type HistogramBucket struct {
+	From  int
+	To    int
+	Count int
+}
+
+// SimulateEarthquakes removes the configured number of random bricks at once from the settled stack,
+// for the configured number of trials, and collects how many bricks fell in each. The bricks are picked
+// up front from the seed, so the results don't depend on the number of workers.
+func (this Physics) SimulateEarthquakes(settledBricks []Brick, config EarthquakeConfig) (*Earthquakes, error) {
+	if config.Removed < 1 || config.Removed > len(settledBricks) {
+		return nil, fmt.Errorf("can't remove %d of the %d bricks at once", config.Removed, len(settledBricks))
+	}
+	if config.Trials < 1 {
+		return nil, fmt.Errorf("the number of trials %d needs to be at least 1", config.Trials)
+	}
+
+	random := rand.New(rand.NewSource(config.Seed))
+	picks := make([][]int, config.Trials)
+	for trial := range picks {
+		picks[trial] = make([]int, config.Removed)
+		for i, index := range random.Perm(len(settledBricks))[:config.Removed] {
+			picks[trial][i] = settledBricks[index].Id
+		}
+	}
+
+	collapses := measureInParallel(this, picks, config.Workers, func(physics Physics, pick []int) int {
+		// The picks are ids of the settled stack, so removing them can't fail.
+		_, fallen, _ := physics.WhatIfRemoved(settledBricks, pick)
+		return len(fallen)
+	})
+
+	sorted := append([]int(nil), collapses...)
+	sort.Ints(sorted)
+	return &Earthquakes{Collapses: collapses, sorted: sorted}, nil
+}
+
+// Mean returns the average number of bricks that fell.
+func (this *Earthquakes) Mean() float64 {
+	total := 0
+	for _, collapse := range this.sorted {
+		total += collapse
+	}
+	return float64(total) / float64(len(this.sorted))
+}
+
+// Percentile returns the smallest collapse size at least the given percentage of the trials didn't exceed.
+func (this *Earthquakes) Percentile(percentage float64) int {
+	rank := int(math.Ceil(percentage / 100 * float64(len(this.sorted))))
+	return this.sorted[min(max(rank, 1), len(this.sorted))-1]
+}
+
+// Histogram splits the range of collapse sizes in at most the given number of equally wide buckets.
+func (this *Earthquakes) Histogram(buckets int) []HistogramBucket {
+	smallest, largest := this.sorted[0], this.sorted[len(this.sorted)-1]
+	width := max((largest-smallest+buckets)/max(buckets, 1), 1)
+
+	var histogram []HistogramBucket
+	for from := smallest; from <= largest; from += width {
+		histogram = append(histogram, HistogramBucket{From: from, To: from + width - 1})
+	}
+	for _, collapse := range this.sorted {
+		histogram[(collapse-smallest)/width].Count++
+	}
+	return histogram
+}
@@ -0,0 +1,44 @@
+package brickphysics
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// FallEvent is a single move of a brick by one level, one frame of a fall animation.
+// FromZ and ToZ are the coordinates along the gravity axis of the side of the brick facing the floor,
+// which is the bottom Z for the puzzle's gravity.
+type FallEvent struct {
+	Tick    int `json:"tick"`
+	BrickId int `json:"brick"`
+	FromZ   int `json:"fromZ"`
+	ToZ     int `json:"toZ"`
+}
+
+// FallRecorder receives every move of a brick while a stack settles.
+type FallRecorder interface {
+	Record(event FallEvent)
+}
+
+// JSONLinesRecorder writes every move it receives as a line of JSON.
+type JSONLinesRecorder struct {
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONLinesRecorder returns a recorder writing to the given writer.
+func NewJSONLinesRecorder(w io.Writer) *JSONLinesRecorder {
+	return &JSONLinesRecorder{encoder: json.NewEncoder(w)}
+}
+
+// Record writes the event, after a failed write all following events are dropped.
+func (this *JSONLinesRecorder) Record(event FallEvent) {
+	if this.err == nil {
+		this.err = this.encoder.Encode(event)
+	}
+}
+
+// Err returns the first error that occurred while writing the events.
+func (this *JSONLinesRecorder) Err() error {
+	return this.err
+}
@@ -0,0 +1,226 @@
+package brickphysics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// BrickColor returns a stable colour for a brick id, spreading the hues of consecutive ids with the golden ratio.
+func BrickColor(id int) color.RGBA {
+	hue := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	saturation, value := 0.65, 0.9
+
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue, 2)-1))
+	m := value - chroma
+
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	return color.RGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 255}
+}
+
+// WriteObj writes the bricks as a Wavefront OBJ mesh with one named object per brick.
+// Most viewers expect the Y axis to point up, so the brick Z axis is written as Y (and Y as -Z).
+func WriteObj(w io.Writer, bricks []Brick) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "# Day 22 brick stack")
+
+	vertexCount := 0
+	for _, brick := range bricks {
+		fmt.Fprintf(writer, "o brick_%d\n", brick.Id)
+
+		// A polycube is written as one box per cell, all part of the same object.
+		for _, box := range brick.boxes() {
+			x0, y0, z0 := box[0].X, box[0].Y, box[0].Z
+			x1, y1, z1 := box[1].X+1, box[1].Y+1, box[1].Z+1
+
+			// The corners of the bottom face followed by the corners of the top face.
+			corners := [8]Coordinate{
+				{X: x0, Y: y0, Z: z0}, {X: x1, Y: y0, Z: z0}, {X: x1, Y: y1, Z: z0}, {X: x0, Y: y1, Z: z0},
+				{X: x0, Y: y0, Z: z1}, {X: x1, Y: y0, Z: z1}, {X: x1, Y: y1, Z: z1}, {X: x0, Y: y1, Z: z1},
+			}
+
+			for _, corner := range corners {
+				fmt.Fprintf(writer, "v %d %d %d\n", corner.X, corner.Z, -corner.Y)
+			}
+
+			// Faces are wound counter-clockwise when seen from outside the brick.
+			faces := [6][4]int{{0, 3, 2, 1}, {4, 5, 6, 7}, {0, 1, 5, 4}, {2, 3, 7, 6}, {3, 0, 4, 7}, {1, 2, 6, 5}}
+			for _, face := range faces {
+				v := vertexCount + 1
+				fmt.Fprintf(writer, "f %d %d %d %d\n", v+face[0], v+face[1], v+face[2], v+face[3])
+			}
+			vertexCount += len(corners)
+		}
+	}
+
+	return writer.Flush()
+}
+
+// The maximum size of a single MagicaVoxel model along every axis.
+const voxModelSize = 256
+
+// voxModel is a part of the stack small enough to fit in a single MagicaVoxel model.
+type voxModel struct {
+	Origin Coordinate
+	Size   Coordinate
+	Voxels [][4]byte
+}
+
+// WriteVox writes the bricks as a MagicaVoxel .vox file.
+// Stacks larger than a single model are split in models of at most 256 voxels along every axis,
+// which a scene graph puts back in place. Each brick gets the palette colour of its id, the
+// palette only has 255 colours so every 255th brick shares its colour.
+func WriteVox(w io.Writer, bricks []Brick) error {
+	models := make(map[Coordinate]*voxModel)
+	var modelKeys []Coordinate
+
+	for _, brick := range bricks {
+		colorIndex := byte((brick.Id-1)%255 + 1)
+
+		for _, voxel := range brick.Voxels() {
+			x, y, z := voxel.X, voxel.Y, voxel.Z
+			key := Coordinate{X: floorDiv(x, voxModelSize), Y: floorDiv(y, voxModelSize), Z: floorDiv(z, voxModelSize)}
+			model, found := models[key]
+			if !found {
+				model = &voxModel{Origin: Coordinate{X: key.X * voxModelSize, Y: key.Y * voxModelSize, Z: key.Z * voxModelSize}, Size: Coordinate{X: 1, Y: 1, Z: 1}}
+				models[key] = model
+				modelKeys = append(modelKeys, key)
+			}
+
+			local := Coordinate{X: x - model.Origin.X, Y: y - model.Origin.Y, Z: z - model.Origin.Z}
+			model.Size = Coordinate{X: max(model.Size.X, local.X+1), Y: max(model.Size.Y, local.Y+1), Z: max(model.Size.Z, local.Z+1)}
+			model.Voxels = append(model.Voxels, [4]byte{byte(local.X), byte(local.Y), byte(local.Z), colorIndex})
+		}
+	}
+
+	// A file always needs at least one model, even for an empty stack.
+	if len(modelKeys) == 0 {
+		modelKeys = append(modelKeys, Coordinate{})
+		models[Coordinate{}] = &voxModel{Size: Coordinate{X: 1, Y: 1, Z: 1}}
+	}
+
+	var children bytes.Buffer
+	for _, key := range modelKeys {
+		model := models[key]
+
+		var size bytes.Buffer
+		writeInt32s(&size, model.Size.X, model.Size.Y, model.Size.Z)
+		writeVoxChunk(&children, "SIZE", size.Bytes())
+
+		var voxels bytes.Buffer
+		writeInt32s(&voxels, len(model.Voxels))
+		for _, voxel := range model.Voxels {
+			voxels.Write(voxel[:])
+		}
+		writeVoxChunk(&children, "XYZI", voxels.Bytes())
+	}
+
+	// The scene graph: a root transform holding a group, holding a transform and shape per model.
+	var rootTransform bytes.Buffer
+	writeInt32s(&rootTransform, 0)
+	writeVoxDict(&rootTransform, nil)
+	writeInt32s(&rootTransform, 1, -1, -1, 1)
+	writeVoxDict(&rootTransform, nil)
+	writeVoxChunk(&children, "nTRN", rootTransform.Bytes())
+
+	var group bytes.Buffer
+	writeInt32s(&group, 1)
+	writeVoxDict(&group, nil)
+	writeInt32s(&group, len(modelKeys))
+	for i := range modelKeys {
+		writeInt32s(&group, 2+2*i)
+	}
+	writeVoxChunk(&children, "nGRP", group.Bytes())
+
+	for i, key := range modelKeys {
+		model := models[key]
+
+		// MagicaVoxel positions the center of a model, rounded down.
+		translation := fmt.Sprintf("%d %d %d", model.Origin.X+model.Size.X/2, model.Origin.Y+model.Size.Y/2, model.Origin.Z+model.Size.Z/2)
+
+		var transform bytes.Buffer
+		writeInt32s(&transform, 2+2*i)
+		writeVoxDict(&transform, nil)
+		writeInt32s(&transform, 3+2*i, -1, 0, 1)
+		writeVoxDict(&transform, [][2]string{{"_t", translation}})
+		writeVoxChunk(&children, "nTRN", transform.Bytes())
+
+		var shape bytes.Buffer
+		writeInt32s(&shape, 3+2*i)
+		writeVoxDict(&shape, nil)
+		writeInt32s(&shape, 1, i)
+		writeVoxDict(&shape, nil)
+		writeVoxChunk(&children, "nSHP", shape.Bytes())
+	}
+
+	// Palette entry i holds the colour of color index i+1.
+	var palette bytes.Buffer
+	for i := 1; i <= 256; i++ {
+		c := BrickColor(i)
+		palette.Write([]byte{c.R, c.G, c.B, c.A})
+	}
+	writeVoxChunk(&children, "RGBA", palette.Bytes())
+
+	var file bytes.Buffer
+	file.WriteString("VOX ")
+	writeInt32s(&file, 150)
+	file.WriteString("MAIN")
+	writeInt32s(&file, 0, children.Len())
+	file.Write(children.Bytes())
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// Helper function to write a MagicaVoxel chunk without children.
+func writeVoxChunk(buffer *bytes.Buffer, id string, content []byte) {
+	buffer.WriteString(id)
+	writeInt32s(buffer, len(content), 0)
+	buffer.Write(content)
+}
+
+// Helper function to write a MagicaVoxel dictionary of string pairs.
+func writeVoxDict(buffer *bytes.Buffer, entries [][2]string) {
+	writeInt32s(buffer, len(entries))
+	for _, entry := range entries {
+		for _, str := range entry {
+			writeInt32s(buffer, len(str))
+			buffer.WriteString(str)
+		}
+	}
+}
+
+// Helper function to write integers as little endian 32 bit integers.
+func writeInt32s(buffer *bytes.Buffer, values ...int) {
+	for _, value := range values {
+		binary.Write(buffer, binary.LittleEndian, int32(value))
+	}
+}
+
+// Helper function to divide and round towards negative infinity.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
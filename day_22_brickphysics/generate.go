@@ -0,0 +1,124 @@
+package brickphysics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// How many random positions the generator tries for a brick before it gives up.
+const maxPlacementAttempts = 1000
+
+// GeneratorConfig describes the random stacks made by Generate.
+// Bricks are placed with their cells in 0..Width-1 along X, 0..Depth-1 along Y and MinZ..MaxZ along Z.
+type GeneratorConfig struct {
+	Seed          int64
+	Width         int
+	Depth         int
+	MinZ          int
+	MaxZ          int
+	Count         int
+	MaxLength     int     // The longest a brick can be, in cells.
+	VerticalRatio float64 // The chance of a brick extending along Z, between 0 and 1.
+}
+
+// NewGeneratorConfig returns a config for a stack shaped like the puzzle input, but smaller.
+func NewGeneratorConfig() GeneratorConfig {
+	return GeneratorConfig{Seed: 1, Width: 10, Depth: 10, MinZ: DefaultFloor, MaxZ: 100, Count: 100, MaxLength: 5, VerticalRatio: 0.1}
+}
+
+// Helper function to check the config describes a space bricks can be placed in.
+func (this GeneratorConfig) validate() error {
+	switch {
+	case this.Width < 1 || this.Depth < 1:
+		return fmt.Errorf("the footprint %dx%d needs to be at least 1x1", this.Width, this.Depth)
+	case this.MinZ < DefaultFloor:
+		return fmt.Errorf("the lowest level %d is below the floor at %d", this.MinZ, DefaultFloor)
+	case this.MaxZ < this.MinZ:
+		return fmt.Errorf("the highest level %d is below the lowest level %d", this.MaxZ, this.MinZ)
+	case this.Count < 0:
+		return fmt.Errorf("the brick count %d is negative", this.Count)
+	case this.MaxLength < 1:
+		return fmt.Errorf("the maximum brick length %d needs to be at least 1", this.MaxLength)
+	case this.VerticalRatio < 0 || this.VerticalRatio > 1:
+		return fmt.Errorf("the vertical ratio %g is not between 0 and 1", this.VerticalRatio)
+	}
+	return nil
+}
+
+// Generate places the configured number of random, non-overlapping bricks.
+// The same config always gives the same bricks, ids count up from 1 in the order they are returned.
+func Generate(config GeneratorConfig) ([]Brick, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	random := rand.New(rand.NewSource(config.Seed))
+	occupied := make(map[Coordinate]bool)
+	bricks := make([]Brick, 0, config.Count)
+
+	for id := 1; id <= config.Count; id++ {
+		placed := false
+
+		for attempt := 0; attempt < maxPlacementAttempts && !placed; attempt++ {
+			brick := randomBrick(random, config)
+
+			overlaps := false
+			for _, cell := range brick.Voxels() {
+				if occupied[cell] {
+					overlaps = true
+					break
+				}
+			}
+			if overlaps {
+				continue
+			}
+
+			for _, cell := range brick.Voxels() {
+				occupied[cell] = true
+			}
+			brick.Id, brick.Line = id, id
+			bricks = append(bricks, brick)
+			placed = true
+		}
+
+		if !placed {
+			return nil, fmt.Errorf("no room for brick %d after %d attempts, try a larger space or fewer bricks", id, maxPlacementAttempts)
+		}
+	}
+
+	return bricks, nil
+}
+
+// Helper function to make a random brick inside the configured space, which may overlap others.
+func randomBrick(random *rand.Rand, config GeneratorConfig) Brick {
+	start := Coordinate{
+		X: random.Intn(config.Width),
+		Y: random.Intn(config.Depth),
+		Z: config.MinZ + random.Intn(config.MaxZ-config.MinZ+1),
+	}
+	end := start
+	length := 1 + random.Intn(config.MaxLength)
+
+	// Grow the brick along its axis, keeping it inside the space.
+	switch {
+	case random.Float64() < config.VerticalRatio:
+		end.Z = min(start.Z+length-1, config.MaxZ)
+	case random.Intn(2) == 0:
+		end.X = min(start.X+length-1, config.Width-1)
+	default:
+		end.Y = min(start.Y+length-1, config.Depth-1)
+	}
+
+	return Brick{Start: start, End: end}
+}
+
+// WriteBricks writes the bricks one per line, in the format ParseBricks reads.
+func WriteBricks(w io.Writer, bricks []Brick) error {
+	writer := bufio.NewWriter(w)
+	for _, brick := range bricks {
+		fmt.Fprintln(writer, brick.ToString())
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,140 @@
+package brickphysics
+
+import (
+	"sort"
+)
+
+// SupportGraph keeps track of which bricks rest on which other bricks in a settled stack.
+// A polycube resting on both the floor and other bricks has FloorId among its supporters,
+// a brick resting on the floor alone has no supporters at all.
+type SupportGraph struct {
+	supportedBy map[int][]int
+	supports    map[int][]int
+}
+
+// NewSupportGraph creates the support graph from the supporters found while settling the bricks.
+func NewSupportGraph(supporters map[int][]int) *SupportGraph {
+	graph := &SupportGraph{supportedBy: make(map[int][]int), supports: make(map[int][]int)}
+
+	for upperID, lowerIDs := range supporters {
+		graph.supportedBy[upperID] = lowerIDs
+		for _, lowerID := range lowerIDs {
+			graph.supports[lowerID] = append(graph.supports[lowerID], upperID)
+		}
+	}
+
+	// Keep the lookups in a stable order, independent of the map iteration order.
+	for lowerID := range graph.supports {
+		sort.Ints(graph.supports[lowerID])
+	}
+
+	return graph
+}
+
+// addBrick adds a brick on top of the graph, resting on the given bricks.
+func (this *SupportGraph) addBrick(id int, lowerIDs []int) {
+	if len(lowerIDs) == 0 {
+		return
+	}
+	this.supportedBy[id] = lowerIDs
+	for _, lowerID := range lowerIDs {
+		this.supports[lowerID] = append(this.supports[lowerID], id)
+		sort.Ints(this.supports[lowerID])
+	}
+}
+
+// SupportedBy returns the ids of the bricks the given brick rests on.
+func (this *SupportGraph) SupportedBy(id int) []int {
+	return this.supportedBy[id]
+}
+
+// Supports returns the ids of the bricks resting on the given brick.
+func (this *SupportGraph) Supports(id int) []int {
+	return this.supports[id]
+}
+
+// Clone returns a copy of the graph that can be changed without affecting this graph.
+func (this *SupportGraph) Clone() *SupportGraph {
+	graph := &SupportGraph{supportedBy: make(map[int][]int), supports: make(map[int][]int)}
+	for id, lowerIDs := range this.supportedBy {
+		graph.supportedBy[id] = append([]int(nil), lowerIDs...)
+	}
+	for id, upperIDs := range this.supports {
+		graph.supports[id] = append([]int(nil), upperIDs...)
+	}
+	return graph
+}
+
+// RemoveBrick takes a brick out of the graph, together with all the supports it was part of.
+func (this *SupportGraph) RemoveBrick(id int) {
+	for _, upperID := range this.supports[id] {
+		this.supportedBy[upperID] = removeId(this.supportedBy[upperID], id)
+	}
+	for _, lowerID := range this.supportedBy[id] {
+		this.supports[lowerID] = removeId(this.supports[lowerID], id)
+	}
+	delete(this.supports, id)
+	delete(this.supportedBy, id)
+}
+
+// topologicalOrder returns the ids of the bricks ordered so every brick comes after all of its supporters.
+// Bricks that don't depend on each other keep their order in the given slice.
+func (this *SupportGraph) topologicalOrder(bricks []Brick) []int {
+	// The floor is always there, so only the supporting bricks are waited for.
+	waitingFor := make(map[int]int)
+	for _, brick := range bricks {
+		waitingFor[brick.Id] = len(removeId(this.SupportedBy(brick.Id), FloorId))
+	}
+
+	var order []int
+	for _, brick := range bricks {
+		if waitingFor[brick.Id] == 0 {
+			order = append(order, brick.Id)
+		}
+	}
+
+	for i := 0; i < len(order); i++ {
+		for _, upperID := range this.Supports(order[i]) {
+			waitingFor[upperID]--
+			if waitingFor[upperID] == 0 {
+				order = append(order, upperID)
+			}
+		}
+	}
+
+	return order
+}
+
+// Helper function to remove an id from a list of ids.
+func removeId(ids []int, id int) []int {
+	result := make([]int, 0, len(ids))
+	for _, other := range ids {
+		if other != id {
+			result = append(result, other)
+		}
+	}
+	return result
+}
+
+// CanBeSafelyRemoved checks if a brick can be safely removed.
+// A brick also resting on the floor never counts as depending on a single brick.
+func CanBeSafelyRemoved(brick Brick, graph *SupportGraph) bool {
+	for _, upperID := range graph.Supports(brick.Id) {
+		// The upper brick would fall if this brick is its only support.
+		if len(graph.SupportedBy(upperID)) == 1 {
+			return false
+		}
+	}
+	return true // No bricks would fall if brick is removed
+}
+
+// CountSafelyRemovableBricks counts the number of bricks that can be safely removed
+func CountSafelyRemovableBricks(bricks []Brick, graph *SupportGraph) int {
+	count := 0
+	for _, brick := range bricks {
+		if CanBeSafelyRemoved(brick, graph) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,150 @@
+package brickphysics
+
+import (
+	"fmt"
+)
+
+// Gravity is the axis direction the bricks fall in.
+type Gravity int
+
+const (
+	NegativeZ Gravity = iota // Falling down, the puzzle's gravity.
+	PositiveZ
+	NegativeX
+	PositiveX
+	NegativeY
+	PositiveY
+)
+
+var gravityNames = map[Gravity]string{
+	NegativeZ: "-z",
+	PositiveZ: "+z",
+	NegativeX: "-x",
+	PositiveX: "+x",
+	NegativeY: "-y",
+	PositiveY: "+y",
+}
+
+func (this Gravity) String() string {
+	return gravityNames[this]
+}
+
+// ParseGravity parses a direction like "-z" or "+x".
+func ParseGravity(str string) (Gravity, error) {
+	for gravity, name := range gravityNames {
+		if name == str {
+			return gravity, nil
+		}
+	}
+	return NegativeZ, fmt.Errorf("unknown gravity %q, expected one of -z, +z, -x, +x, -y or +y", str)
+}
+
+// isPositive checks if the bricks fall towards increasing coordinates.
+func (this Gravity) isPositive() bool {
+	return this == PositiveZ || this == PositiveX || this == PositiveY
+}
+
+// toFrame maps a coordinate into the frame where the bricks fall along -Z, the frame all settling happens in.
+func (this Gravity) toFrame(c Coordinate) Coordinate {
+	switch this {
+	case PositiveZ:
+		return Coordinate{X: c.X, Y: c.Y, Z: -c.Z}
+	case NegativeX:
+		return Coordinate{X: c.Z, Y: c.Y, Z: c.X}
+	case PositiveX:
+		return Coordinate{X: c.Z, Y: c.Y, Z: -c.X}
+	case NegativeY:
+		return Coordinate{X: c.X, Y: c.Z, Z: c.Y}
+	case PositiveY:
+		return Coordinate{X: c.X, Y: c.Z, Z: -c.Y}
+	}
+	return c
+}
+
+// fromFrame maps a coordinate from the frame where the bricks fall along -Z back to the world.
+func (this Gravity) fromFrame(c Coordinate) Coordinate {
+	switch this {
+	case PositiveZ:
+		return Coordinate{X: c.X, Y: c.Y, Z: -c.Z}
+	case NegativeX:
+		return Coordinate{X: c.Z, Y: c.Y, Z: c.X}
+	case PositiveX:
+		return Coordinate{X: -c.Z, Y: c.Y, Z: c.X}
+	case NegativeY:
+		return Coordinate{X: c.X, Y: c.Z, Z: c.Y}
+	case PositiveY:
+		return Coordinate{X: c.X, Y: -c.Z, Z: c.Y}
+	}
+	return c
+}
+
+// Helper function to map both ends of a brick, keeping the start the smallest corner.
+// The cells of a polycube are mapped one by one.
+func transformBrick(brick Brick, transform func(Coordinate) Coordinate) Brick {
+	if !brick.IsBox() {
+		voxels := brick.Voxels()
+		for i := range voxels {
+			voxels[i] = transform(voxels[i])
+		}
+		return brick.withVoxels(voxels)
+	}
+
+	a, b := transform(brick.Start), transform(brick.End)
+	brick.Start = Coordinate{X: min(a.X, b.X), Y: min(a.Y, b.Y), Z: min(a.Z, b.Z)}
+	brick.End = Coordinate{X: max(a.X, b.X), Y: max(a.Y, b.Y), Z: max(a.Z, b.Z)}
+	return brick
+}
+
+// toFrame maps a brick into the frame where it falls along -Z.
+func (this Physics) toFrame(brick Brick) Brick {
+	if this.Gravity == NegativeZ {
+		return brick
+	}
+	return transformBrick(brick, this.Gravity.toFrame)
+}
+
+// fromFrame maps a brick from the frame where it falls along -Z back to the world.
+func (this Physics) fromFrame(brick Brick) Brick {
+	if this.Gravity == NegativeZ {
+		return brick
+	}
+	return transformBrick(brick, this.Gravity.fromFrame)
+}
+
+// frameFloor returns the floor level in the frame where the bricks fall along -Z.
+func (this Physics) frameFloor() int {
+	if this.Gravity.isPositive() {
+		return -this.Floor
+	}
+	return this.Floor
+}
+
+// worldLevel maps a level along -Z in the settling frame back to the coordinate along the gravity axis.
+func (this Physics) worldLevel(frameZ int) int {
+	if this.Gravity.isPositive() {
+		return -frameZ
+	}
+	return frameZ
+}
+
+// Level returns the coordinate along the gravity axis of the side of the brick facing the floor.
+// For the puzzle's gravity that is the bottom Z of the brick.
+func (this Physics) Level(brick Brick) int {
+	return this.worldLevel(this.toFrame(brick).Start.Z)
+}
+
+// IsSupportedBy checks if the upper brick rests directly on the lower brick along the gravity axis.
+func (this Physics) IsSupportedBy(upper, lower Brick) bool {
+	upperInFrame, lowerInFrame := this.toFrame(upper), this.toFrame(lower)
+	return upperInFrame.IsSupportedBy(&lowerInFrame)
+}
+
+// CheckFloor verifies that no brick starts beyond the floor, which would make it fall the wrong way.
+func (this Physics) CheckFloor(bricks []Brick) error {
+	for _, brick := range bricks {
+		if this.toFrame(brick).Start.Z < this.frameFloor() {
+			return fmt.Errorf("brick %d (%s) lies beyond the floor at %d for gravity %s", brick.Id, brick.ToString(), this.Floor, this.Gravity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package brickphysics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimulateFallRejectsBricksBeyondTheFloor(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader("5,0,0~5,0,2\n8,0,1~8,0,1\n9,0,0~9,0,2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	physics := Physics{Floor: 20, Gravity: NegativeX}
+
+	if _, _, _, err := physics.SimulateFall(bricks); err == nil {
+		t.Fatalf("SimulateFall settled bricks lying beyond the floor at X %d", physics.Floor)
+	}
+	if _, _, err := physics.NewStack().Drop(bricks[0]); err == nil {
+		t.Fatalf("Drop settled brick %d lying beyond the floor at X %d", bricks[0].Id, physics.Floor)
+	}
+}
@@ -0,0 +1,91 @@
+package brickphysics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The most cells WriteGrid and WriteTopGrid print, so a sparse or huge stack can't flood the output.
+const maxGridCells = 1 << 20
+
+// WriteGrid prints the grid from the X or Y view, one line per level from the top down.
+// The columns start at the lowest X or Y of the bricks, like WriteSvg, so negative coordinates fit.
+// A grid of more than maxGridCells cells is refused with an error.
+func WriteGrid(w io.Writer, bricks []Brick, view string) error {
+	minX, minY, maxX, maxY, minZ, maxZ := 0, 0, 0, 0, 0, 0
+	for i, brick := range bricks {
+		if i == 0 {
+			minX, minY, maxX, maxY = brick.Start.X, brick.Start.Y, brick.End.X, brick.End.Y
+		}
+		minX, minY, minZ = min(minX, brick.Start.X), min(minY, brick.Start.Y), min(minZ, brick.Start.Z)
+		maxX, maxY, maxZ = max(maxX, brick.End.X), max(maxY, brick.End.Y), max(maxZ, brick.End.Z)
+	}
+
+	minH, maxH := minX, maxX
+	if view != "X" {
+		minH, maxH = minY, maxY
+	}
+	if err := checkGridSize(maxH-minH+1, maxZ-minZ+1); err != nil {
+		return err
+	}
+
+	for z := maxZ; z >= minZ; z-- {
+		fmt.Fprintf(w, "Level %d: ", z)
+		gridLine := strings.Repeat(" ", (maxH-minH+1)*2) // Initialize with spaces for empty bricks
+		for _, brick := range bricks {
+			for _, box := range brick.boxes() {
+				if z >= box[0].Z && z <= box[1].Z {
+					start, end := box[0].X, box[1].X
+					if view != "X" {
+						start, end = box[0].Y, box[1].Y
+					}
+					brickRepresentation := strings.Repeat("==", end-start+1)
+					position := (start - minH) * 2
+					gridLine = gridLine[:position] + brickRepresentation + gridLine[position+len(brickRepresentation):]
+				}
+			}
+		}
+
+		fmt.Fprintln(w, "["+gridLine+"]")
+	}
+	return nil
+}
+
+// WriteTopGrid prints the grid seen from above, one line per Y row from the back to the front.
+// Like WriteGrid it starts at the lowest X and Y of the bricks and refuses grids of more than maxGridCells cells.
+func WriteTopGrid(w io.Writer, bricks []Brick) error {
+	footprint := Footprint(bricks)
+	if err := checkGridSize(footprint.Dx(), footprint.Dy()); err != nil {
+		return err
+	}
+
+	covered := make(map[Point]bool)
+	for _, brick := range bricks {
+		for _, point := range brick.GetCoveredPoints() {
+			covered[point] = true
+		}
+	}
+
+	for y := footprint.Max.Y - 1; y >= footprint.Min.Y; y-- {
+		fmt.Fprintf(w, "Row %d: ", y)
+		var gridLine strings.Builder
+		for x := footprint.Min.X; x < footprint.Max.X; x++ {
+			if covered[Point{X: x, Y: y}] {
+				gridLine.WriteString("==")
+			} else {
+				gridLine.WriteString("  ")
+			}
+		}
+		fmt.Fprintln(w, "["+gridLine.String()+"]")
+	}
+	return nil
+}
+
+// Helper function to refuse printing a grid of more than maxGridCells cells.
+func checkGridSize(width, height int) error {
+	if width > 0 && height > maxGridCells/width {
+		return fmt.Errorf("a grid of %d by %d cells is too large to print, export the stack with -svg instead", width, height)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package brickphysics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteGridHandlesNegativeCoordinates(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader("-2,0,1~1,0,1\n0,-1,3~0,2,3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var grid strings.Builder
+	if err := WriteGrid(&grid, bricks, "X"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(grid.String(), "Level 1: [========]") {
+		t.Fatalf("brick 1 is missing from the X view:\n%s", grid.String())
+	}
+
+	grid.Reset()
+	if err := WriteTopGrid(&grid, bricks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(grid.String(), "Row -1: [    ==  ]") {
+		t.Fatalf("brick 2 is missing from the top view:\n%s", grid.String())
+	}
+}
+
+func TestWriteGridRefusesHugeGrids(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader("0,0,1~0,0,1\n3000000,0,1~3000000,0,1\n0,5000,2~0,5000,2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var grid strings.Builder
+	if err := WriteGrid(&grid, bricks, "X"); err == nil {
+		t.Fatalf("WriteGrid printed %d bytes for a grid 3000001 cells wide", grid.Len())
+	}
+	if err := WriteTopGrid(&grid, bricks); err == nil {
+		t.Fatalf("WriteTopGrid printed %d bytes for a grid of 3000001 by 5001 cells", grid.Len())
+	}
+}
@@ -0,0 +1,110 @@
+package brickphysics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The rules a settled stack has to follow.
+type InvariantRule string
+
+const (
+	BelowFloor    InvariantRule = "below floor"
+	SharedVoxel   InvariantRule = "shared voxel"
+	FloatingBrick InvariantRule = "floating brick"
+)
+
+// InvariantViolation is a single brick breaking a rule of a settled stack.
+type InvariantViolation struct {
+	BrickId int
+	Rule    InvariantRule
+	Message string
+}
+
+func (this InvariantViolation) Error() string {
+	return fmt.Sprintf("brick %d: %s: %s", this.BrickId, this.Rule, this.Message)
+}
+
+// InvariantViolations holds every rule broken by a stack, in the order of its bricks.
+type InvariantViolations []InvariantViolation
+
+func (this InvariantViolations) Error() string {
+	messages := make([]string, len(this))
+	for i, violation := range this {
+		messages[i] = violation.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// CheckSettled verifies the bricks form a settled stack: no brick lies beyond the floor, no two bricks
+// share a voxel, every brick rests on the floor or on at least one other brick, and so no brick can
+// move any further along the gravity axis. A floating brick is reported with how far it can still fall.
+// Every broken rule is returned together as InvariantViolations.
+func (this Physics) CheckSettled(bricks []Brick) error {
+	var violations InvariantViolations
+	floor := this.frameFloor()
+
+	// Check the bricks with all axes compressed, so huge bricks don't need a cell for every coordinate.
+	// The floor gets a boundary of its own, so the compressed cell a brick can fall to starts at a real level.
+	framed := make([]Brick, len(bricks))
+	for i := range bricks {
+		framed[i] = this.toFrame(bricks[i])
+	}
+	compression := newCompression(framed, true)
+	compression.addBoundary(2, floor)
+	compressedFloor := sort.SearchInts(compression.boundaries[2], floor)
+
+	compressed := make([]Brick, len(bricks))
+	occupied := make(map[Coordinate]int)
+	for i, brick := range bricks {
+		compressed[i] = compression.compress(framed[i])
+
+		if framed[i].Start.Z < floor {
+			violations = append(violations, InvariantViolation{BrickId: brick.Id, Rule: BelowFloor, Message: fmt.Sprintf("%s (line %d) lies %d level(s) beyond the floor at %d", brick.ToString(), brick.Line, floor-framed[i].Start.Z, this.Floor)})
+		}
+
+		for _, voxel := range compressed[i].Voxels() {
+			if other, found := occupied[voxel]; found {
+				world := this.Gravity.fromFrame(compression.expand(voxel))
+				violations = append(violations, InvariantViolation{BrickId: brick.Id, Rule: SharedVoxel, Message: fmt.Sprintf("%s (line %d) shares %d,%d,%d with brick %d", brick.ToString(), brick.Line, world.X, world.Y, world.Z, other)})
+				continue
+			}
+			occupied[voxel] = brick.Id
+		}
+	}
+
+	for i, brick := range bricks {
+		if framed[i].Start.Z <= floor {
+			continue
+		}
+
+		// Count the compressed levels the brick can drop before one of its cells runs into the floor or another brick.
+		voxels := compressed[i].Voxels()
+		freeLevels := 0
+		for blocked := false; !blocked; {
+			if compressed[i].Start.Z-freeLevels == compressedFloor {
+				break
+			}
+			for _, voxel := range voxels {
+				below := Coordinate{X: voxel.X, Y: voxel.Y, Z: voxel.Z - freeLevels - 1}
+				if other, found := occupied[below]; found && other != brick.Id {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				freeLevels++
+			}
+		}
+		if freeLevels > 0 {
+			distance := framed[i].Start.Z - compression.boundaries[2][compressed[i].Start.Z-freeLevels]
+			violations = append(violations, InvariantViolation{BrickId: brick.Id, Rule: FloatingBrick, Message: fmt.Sprintf("%s (line %d) rests on neither the floor nor another brick, it can still fall %d level(s)", brick.ToString(), brick.Line, distance)})
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package brickphysics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSettledAcceptsSettledStack(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader(exampleInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPhysics().CheckSettled(settledStack(t, bricks)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckSettledHandlesHugeBricks(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader("0,0,10~1000000000,0,10\n500000000,0,20~500000000,0,20\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, ok := NewPhysics().CheckSettled(bricks).(InvariantViolations)
+	if !ok || len(violations) != 2 || violations[0].Rule != FloatingBrick || !strings.Contains(violations[1].Message, "can still fall 9 level(s)") {
+		t.Fatalf("unexpected violations:\n%v", violations)
+	}
+	if err := NewPhysics().CheckSettled(settledStack(t, bricks)); err != nil {
+		t.Fatal(err)
+	}
+}
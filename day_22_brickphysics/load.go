@@ -0,0 +1,70 @@
+package brickphysics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BrickLoad is the load a single brick of a settled stack bears.
+type BrickLoad struct {
+	Id         int
+	Line       int
+	Weight     int     // The weight of the brick itself.
+	Load       float64 // The weight of the bricks above, resting on this brick.
+	Overloaded bool    // Whether the load is over the capacity of the report.
+}
+
+// Weight returns the weight of the brick, one per cell it occupies.
+// For a box that is its covered points times its extent along Z.
+func (this *Brick) Weight() int {
+	return this.Volume()
+}
+
+// ComputeLoads propagates the weight of every brick down the support graph.
+// A brick passes its own weight plus the load it bears on to its supporters, split evenly
+// between them. Bricks resting on the floor pass it on to the floor, a polycube resting on both the
+// floor and other bricks counts the floor as one of its supporters. The bricks are handled
+// from the top down, so a brick only passes on its load once every brick above it is done.
+func ComputeLoads(bricks []Brick, graph *SupportGraph) (map[int]float64, error) {
+	weights := make(map[int]int)
+	for i := range bricks {
+		weights[bricks[i].Id] = bricks[i].Weight()
+	}
+
+	order := graph.topologicalOrder(bricks)
+	if len(order) != len(bricks) {
+		return nil, fmt.Errorf("the support graph has a cycle, %d of %d bricks can be ordered", len(order), len(bricks))
+	}
+
+	loads := make(map[int]float64)
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		supporters := graph.SupportedBy(id)
+		for _, lowerID := range supporters {
+			loads[lowerID] += (float64(weights[id]) + loads[id]) / float64(len(supporters))
+		}
+	}
+
+	// A polycube partly resting on the floor passed a share on to the floor, which isn't a brick.
+	delete(loads, FloorId)
+
+	return loads, nil
+}
+
+// LoadReport lists the load of every brick in id order, flagging the bricks bearing more than the capacity.
+// A capacity of zero or less flags no bricks.
+func LoadReport(bricks []Brick, graph *SupportGraph, capacity float64) ([]BrickLoad, error) {
+	loads, err := ComputeLoads(bricks, graph)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]BrickLoad, len(bricks))
+	for i := range bricks {
+		load := loads[bricks[i].Id]
+		report[i] = BrickLoad{Id: bricks[i].Id, Line: bricks[i].Line, Weight: bricks[i].Weight(), Load: load, Overloaded: capacity > 0 && load > capacity}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Id < report[j].Id })
+
+	return report, nil
+}
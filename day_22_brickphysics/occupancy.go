@@ -0,0 +1,137 @@
+package brickphysics
+
+import (
+	"sort"
+)
+
+// OccupancyIndex answers which bricks occupy a cell, a box or a Z level without scanning every brick.
+// Coordinates are in the world, whatever the gravity. When set as the Index of the physics, the
+// settling engine keeps it up to date as bricks land, so it always holds where every brick is now.
+// It keeps the boxes of the bricks ordered by their bottom Z rather than every cell, so its memory
+// grows with the number of bricks however large they are. A query only looks at the boxes starting
+// within the height of the tallest brick below it.
+type OccupancyIndex struct {
+	boxes   []indexedBox  // The boxes of every brick, ordered by their bottom Z.
+	bricks  map[int]Brick // Every brick in the index by id, as it was placed.
+	tallest int           // The most levels a box spans, never lowered as bricks are removed.
+}
+
+// A box of a brick in the index, between two corners.
+type indexedBox struct {
+	min, max Coordinate
+	id       int
+}
+
+// NewOccupancyIndex returns an index holding the given bricks.
+func NewOccupancyIndex(bricks []Brick) *OccupancyIndex {
+	index := &OccupancyIndex{bricks: make(map[int]Brick)}
+	for _, brick := range bricks {
+		index.Place(brick)
+	}
+	return index
+}
+
+// Clone returns an independent copy of the index, for example to use during a what-if.
+func (this *OccupancyIndex) Clone() *OccupancyIndex {
+	clone := &OccupancyIndex{boxes: append([]indexedBox(nil), this.boxes...), bricks: make(map[int]Brick, len(this.bricks)), tallest: this.tallest}
+	for id, brick := range this.bricks {
+		clone.bricks[id] = brick
+	}
+	return clone
+}
+
+// Place puts the brick in the index, moving it when a brick with the same id is in there already.
+func (this *OccupancyIndex) Place(brick Brick) {
+	this.Remove(brick.Id)
+
+	for _, box := range brick.boxes() {
+		i := this.firstFrom(box[0].Z)
+		this.boxes = append(this.boxes, indexedBox{})
+		copy(this.boxes[i+1:], this.boxes[i:])
+		this.boxes[i] = indexedBox{min: box[0], max: box[1], id: brick.Id}
+		this.tallest = max(this.tallest, box[1].Z-box[0].Z+1)
+	}
+	this.bricks[brick.Id] = brick
+}
+
+// Remove takes the brick with the given id out of the index, if it is in there.
+func (this *OccupancyIndex) Remove(id int) {
+	brick, found := this.bricks[id]
+	if !found {
+		return
+	}
+
+	for _, box := range brick.boxes() {
+		for i := this.firstFrom(box[0].Z); i < len(this.boxes) && this.boxes[i].min.Z == box[0].Z; i++ {
+			if this.boxes[i].id == id && this.boxes[i].min == box[0] {
+				this.boxes = append(this.boxes[:i], this.boxes[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(this.bricks, id)
+}
+
+// Helper function to find the first box with a bottom Z of at least the given Z.
+func (this *OccupancyIndex) firstFrom(z int) int {
+	return sort.Search(len(this.boxes), func(i int) bool { return this.boxes[i].min.Z >= z })
+}
+
+// Helper function to find the ids of the bricks with a box overlapping the box between the two corners.
+func (this *OccupancyIndex) overlapping(minCorner, maxCorner Coordinate) map[int]bool {
+	found := make(map[int]bool)
+	for i := this.firstFrom(minCorner.Z - this.tallest + 1); i < len(this.boxes) && this.boxes[i].min.Z <= maxCorner.Z; i++ {
+		box := this.boxes[i]
+		if box.min.X <= maxCorner.X && box.max.X >= minCorner.X && box.min.Y <= maxCorner.Y && box.max.Y >= minCorner.Y && box.max.Z >= minCorner.Z {
+			found[box.id] = true
+		}
+	}
+	return found
+}
+
+// Helper function to turn a set of ids into a slice in id order.
+func sortedIds(found map[int]bool) []int {
+	ids := make([]int, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Len returns the number of bricks in the index.
+func (this *OccupancyIndex) Len() int {
+	return len(this.bricks)
+}
+
+// Brick returns the brick with the given id as it is in the index.
+func (this *OccupancyIndex) Brick(id int) (Brick, bool) {
+	brick, found := this.bricks[id]
+	return brick, found
+}
+
+// BrickAt returns the id of the brick occupying the cell, if any.
+func (this *OccupancyIndex) BrickAt(cell Coordinate) (int, bool) {
+	for id := range this.overlapping(cell, cell) {
+		return id, true
+	}
+	return 0, false
+}
+
+// BricksAtLevel returns the ids of the bricks with a cell at the given Z, in id order.
+func (this *OccupancyIndex) BricksAtLevel(z int) []int {
+	found := make(map[int]bool)
+	for i := this.firstFrom(z - this.tallest + 1); i < len(this.boxes) && this.boxes[i].min.Z <= z; i++ {
+		if this.boxes[i].max.Z >= z {
+			found[this.boxes[i].id] = true
+		}
+	}
+	return sortedIds(found)
+}
+
+// BricksInBox returns the ids of the bricks with a cell inside the box between the two corners, in id order.
+func (this *OccupancyIndex) BricksInBox(a, b Coordinate) []int {
+	minCorner := Coordinate{X: min(a.X, b.X), Y: min(a.Y, b.Y), Z: min(a.Z, b.Z)}
+	maxCorner := Coordinate{X: max(a.X, b.X), Y: max(a.Y, b.Y), Z: max(a.Z, b.Z)}
+	return sortedIds(this.overlapping(minCorner, maxCorner))
+}
@@ -0,0 +1,36 @@
+package brickphysics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOccupancyIndexHandlesHugeBricks(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader("0,0,1~1000000000,0,1\n500000000,0,2~500000000,0,4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := NewOccupancyIndex(bricks)
+
+	if id, found := index.BrickAt(Coordinate{X: 999999999, Y: 0, Z: 1}); !found || id != 1 {
+		t.Fatalf("the cell near the end of brick 1 holds brick %d, found %t", id, found)
+	}
+	if _, found := index.BrickAt(Coordinate{X: 999999999, Y: 1, Z: 1}); found {
+		t.Fatal("an empty cell next to brick 1 holds a brick")
+	}
+	if ids := index.BricksAtLevel(3); !reflect.DeepEqual(ids, []int{2}) {
+		t.Fatalf("level 3 holds bricks %v instead of [2]", ids)
+	}
+	if ids := index.BricksInBox(Coordinate{X: 500000000, Y: 5, Z: 1}, Coordinate{X: 0, Y: 0, Z: 9}); !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Fatalf("the box holds bricks %v instead of [1 2]", ids)
+	}
+
+	index.Remove(2)
+	if ids := index.BricksAtLevel(3); len(ids) != 0 {
+		t.Fatalf("level 3 still holds bricks %v after removing brick 2", ids)
+	}
+	if index.Len() != 1 {
+		t.Fatalf("the index holds %d bricks instead of 1", index.Len())
+	}
+}
@@ -0,0 +1,64 @@
+package brickphysics
+
+import (
+	"runtime"
+	"sync"
+)
+
+// CountFallsInParallel is CountFallsByResimulation spread over a pool of worker goroutines, see measureInParallel.
+// Zero or fewer workers uses one per CPU.
+func (this Physics) CountFallsInParallel(settledBricks []Brick, workers int) map[int]int {
+	return this.measureRemovalsInParallel(settledBricks, workers, func(physics Physics, id int) int {
+		// Removing bricks from a settled stack leaves no brick beyond the floor, so settling it again can't fail.
+		_, fallCount, _, _ := physics.SimulateFall(SnapshotWithout(settledBricks, id))
+		return fallCount
+	})
+}
+
+// Helper function to remove every brick one at a time on a pool of workers, returning the measure of each removal by id.
+func (this Physics) measureRemovalsInParallel(settledBricks []Brick, workers int, measure func(physics Physics, id int) int) map[int]int {
+	ids := make([]int, len(settledBricks))
+	for i, brick := range settledBricks {
+		ids[i] = brick.Id
+	}
+
+	values := make(map[int]int)
+	for i, value := range measureInParallel(this, ids, workers, measure) {
+		values[ids[i]] = value
+	}
+	return values
+}
+
+// Helper function to measure every job on a pool of workers, returning the measures in the order of the jobs.
+// Zero or fewer workers uses one per CPU. The measures share the settled stack, so they may only read it and
+// have to settle a copy, like SimulateFall on SnapshotWithout and WhatIfRemoved do. Recording and indexing are
+// turned off, as the recorder and the index are not safe for concurrent use.
+func measureInParallel[Job any](physics Physics, jobs []Job, workers int, measure func(physics Physics, job Job) int) []int {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	physics.Recorder = nil
+	physics.Index = nil
+
+	// Every worker writes the measures of its own jobs, so they need no locking.
+	values := make([]int, len(jobs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				values[i] = measure(physics, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return values
+}
@@ -0,0 +1,27 @@
+package brickphysics
+
+import (
+	"testing"
+)
+
+func TestCountFallsInParallelMatchesSerial(t *testing.T) {
+	bricks, err := Generate(GeneratorConfig{Seed: 7, Width: 6, Depth: 6, MinZ: DefaultFloor, MaxZ: 150, Count: 120, MaxLength: 4, VerticalRatio: 0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settled := settledStack(t, bricks)
+
+	physics := NewPhysics()
+	serial := physics.CountFallsByResimulation(settled)
+	for _, workers := range []int{0, 1, 3, 8} {
+		parallel := physics.CountFallsInParallel(settled, workers)
+		if len(parallel) != len(serial) {
+			t.Fatalf("%d workers: got fall counts for %d bricks instead of %d", workers, len(parallel), len(serial))
+		}
+		for id, fallCount := range serial {
+			if parallel[id] != fallCount {
+				t.Fatalf("%d workers: removing brick %d made %d bricks fall instead of %d", workers, id, parallel[id], fallCount)
+			}
+		}
+	}
+}
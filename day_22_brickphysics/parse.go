@@ -0,0 +1,224 @@
+package brickphysics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The kinds of problems the parser reports for a line of the input.
+type ParseErrorKind string
+
+const (
+	MissingSeparator  ParseErrorKind = "missing separator"
+	InvalidCoordinate ParseErrorKind = "invalid coordinate"
+	InvalidNumber     ParseErrorKind = "invalid number"
+	NotAxisAligned    ParseErrorKind = "not axis aligned"
+	OverlappingBricks ParseErrorKind = "overlapping bricks"
+	InvalidShape      ParseErrorKind = "invalid shape"
+)
+
+// ParseError is a single problem found on a line of the input.
+type ParseError struct {
+	Line    int
+	Kind    ParseErrorKind
+	Message string
+}
+
+func (this ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", this.Line, this.Kind, this.Message)
+}
+
+// ParseErrors holds every problem found in the input, in line order.
+type ParseErrors []ParseError
+
+func (this ParseErrors) Error() string {
+	messages := make([]string, len(this))
+	for i, parseError := range this {
+		messages[i] = parseError.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Helper function to parse a "x,y,z" string to a coordinate, reporting problems on the given line.
+func parseCoordinate(str string, line int) (Coordinate, []ParseError) {
+	values := strings.Split(str, ",")
+	if len(values) != 3 {
+		return Coordinate{}, []ParseError{{Line: line, Kind: InvalidCoordinate, Message: fmt.Sprintf("%q has %d values instead of 3", str, len(values))}}
+	}
+
+	var numbers [3]int
+	var problems []ParseError
+	for i, value := range values {
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			problems = append(problems, ParseError{Line: line, Kind: InvalidNumber, Message: fmt.Sprintf("%q is not a number", value)})
+		}
+		numbers[i] = number
+	}
+
+	return Coordinate{X: numbers[0], Y: numbers[1], Z: numbers[2]}, problems
+}
+
+// Helper function to parse a "x,y,z@x,y,z;x,y,z;..." polycube line, the origin followed by the offset of every cell.
+func parsePolycubeLine(line string, lineNumber int) (Brick, []ParseError) {
+	parts := strings.Split(line, "@")
+	if len(parts) != 2 {
+		return Brick{}, []ParseError{{Line: lineNumber, Kind: MissingSeparator, Message: fmt.Sprintf("%q does not have exactly one \"@\"", line)}}
+	}
+
+	origin, problems := parseCoordinate(parts[0], lineNumber)
+	var offsets []Coordinate
+	for _, cell := range strings.Split(parts[1], ";") {
+		offset, cellProblems := parseCoordinate(cell, lineNumber)
+		problems = append(problems, cellProblems...)
+		offsets = append(offsets, offset)
+	}
+	if len(problems) > 0 {
+		return Brick{}, problems
+	}
+
+	brick, err := NewPolycube(0, origin, offsets)
+	if err != nil {
+		return Brick{}, []ParseError{{Line: lineNumber, Kind: InvalidShape, Message: err.Error()}}
+	}
+	brick.Line = lineNumber
+	return brick, nil
+}
+
+// Helper function to parse a single "x,y,z~x,y,z" or polycube line to a brick without an id, reporting problems on the given line.
+func parseBrickLine(line string, lineNumber int) (Brick, []ParseError) {
+	if strings.Contains(line, "@") {
+		return parsePolycubeLine(line, lineNumber)
+	}
+
+	// Read part 1 to get start position and part 2 to get end position.
+	parts := strings.Split(line, "~")
+	if len(parts) != 2 {
+		return Brick{}, []ParseError{{Line: lineNumber, Kind: MissingSeparator, Message: fmt.Sprintf("%q does not have exactly one \"~\"", line)}}
+	}
+
+	startCoordinate, startProblems := parseCoordinate(parts[0], lineNumber)
+	endCoordinate, endProblems := parseCoordinate(parts[1], lineNumber)
+	if len(startProblems) > 0 || len(endProblems) > 0 {
+		return Brick{}, append(startProblems, endProblems...)
+	}
+
+	// Normalise reversed endpoints.
+	startCoordinate.X, endCoordinate.X = min(startCoordinate.X, endCoordinate.X), max(startCoordinate.X, endCoordinate.X)
+	startCoordinate.Y, endCoordinate.Y = min(startCoordinate.Y, endCoordinate.Y), max(startCoordinate.Y, endCoordinate.Y)
+	startCoordinate.Z, endCoordinate.Z = min(startCoordinate.Z, endCoordinate.Z), max(startCoordinate.Z, endCoordinate.Z)
+
+	// A brick is a straight line of cubes, so it can only extend along a single axis.
+	extendedAxes := 0
+	for _, extended := range []bool{startCoordinate.X != endCoordinate.X, startCoordinate.Y != endCoordinate.Y, startCoordinate.Z != endCoordinate.Z} {
+		if extended {
+			extendedAxes++
+		}
+	}
+	if extendedAxes > 1 {
+		return Brick{}, []ParseError{{Line: lineNumber, Kind: NotAxisAligned, Message: fmt.Sprintf("%q extends along %d axes", line, extendedAxes)}}
+	}
+
+	return Brick{Line: lineNumber, Start: startCoordinate, End: endCoordinate}, nil
+}
+
+// ParseBrick parses a single "x,y,z~x,y,z" brick or polycube, for example one arriving from a feed.
+// The brick gets no id, that is up to the caller.
+func ParseBrick(line string) (Brick, error) {
+	brick, problems := parseBrickLine(strings.TrimSpace(line), 1)
+	if len(problems) > 0 {
+		return Brick{}, ParseErrors(problems)
+	}
+	return brick, nil
+}
+
+// ParseBricks reads one "x,y,z~x,y,z" brick per line and validates the whole stack.
+// A line can also hold a polycube as "x,y,z@x,y,z;x,y,z;...", its origin followed by the offset of every cell.
+// Reversed endpoints are swapped so the start is never greater than the end. Blank lines are skipped.
+// All problems are collected and returned together as ParseErrors, with their line numbers.
+func ParseBricks(reader io.Reader) ([]Brick, error) {
+	var bricks []Brick
+	var problems ParseErrors
+	var nextID int = 1
+
+	lineNumber := 0
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		brick, lineProblems := parseBrickLine(line, lineNumber)
+		if len(lineProblems) > 0 {
+			problems = append(problems, lineProblems...)
+			continue
+		}
+		brick.Id = nextID
+		nextID++
+
+		bricks = append(bricks, brick)
+	}
+
+	// Handle file reading error.
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Report the overlaps together with the other problems, in line order.
+	problems = append(problems, findOverlaps(bricks)...)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return bricks, nil
+}
+
+// Helper function to report every brick overlapping a brick on an earlier line, once per pair of bricks.
+// The cells are compressed along all axes first, so huge bricks don't need a cell for every coordinate.
+func findOverlaps(bricks []Brick) []ParseError {
+	var problems []ParseError
+	compression := newCompression(bricks, true)
+
+	// Remember which brick occupies each cell, to find overlapping bricks.
+	occupied := make(map[Coordinate]Brick)
+	for _, brick := range bricks {
+		// Claim the cells of the brick, reporting every other brick it runs into once.
+		overlapsWith := make(map[int]bool)
+		compressed := compression.compress(brick)
+		for _, cell := range compressed.Voxels() {
+			if other, found := occupied[cell]; found {
+				if !overlapsWith[other.Id] {
+					overlapsWith[other.Id] = true
+					at := compression.expand(cell)
+					problems = append(problems, ParseError{Line: brick.Line, Kind: OverlappingBricks, Message: fmt.Sprintf("overlaps the brick on line %d at %d,%d,%d", other.Line, at.X, at.Y, at.Z)})
+				}
+				continue
+			}
+			occupied[cell] = brick
+		}
+	}
+
+	return problems
+}
+
+// ParseFile reads and validates the bricks in the file at the given path.
+func ParseFile(path string) ([]Brick, error) {
+	// Open file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return ParseBricks(file)
+}
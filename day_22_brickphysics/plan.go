@@ -0,0 +1,60 @@
+package brickphysics
+
+import (
+	"fmt"
+)
+
+// DemolitionStep is a single removal of a demolition plan together with its consequence.
+type DemolitionStep struct {
+	Id        int   // The brick removed in this step.
+	FallCount int   // The number of bricks falling because of the removal.
+	Weakened  []int // The bricks that lost a supporter, but are still held up by another one.
+}
+
+// PlanDemolition returns an order to dismantle the whole stack one brick at a time.
+// Every step removes a brick for which CanBeSafelyRemoved holds on the remaining stack, preferring the one farthest
+// from the floor along the gravity axis. A brick supporting nothing is always safe, and every acyclic support graph
+// has such a brick, so a plan without any falling bricks always exists. The farthest brick need not be one of them:
+// a polycube can reach above a brick it carries. Only a support graph containing a cycle can leave no safe brick,
+// which is reported as an error.
+func (this Physics) PlanDemolition(bricks []Brick, graph *SupportGraph) ([]DemolitionStep, error) {
+	graph = graph.Clone()
+	remaining := append([]Brick(nil), bricks...)
+
+	// The level of the side of each brick facing away from the floor.
+	topLevels := make(map[int]int)
+	for _, brick := range remaining {
+		topLevels[brick.Id] = this.toFrame(brick).End.Z
+	}
+	isFarther := func(a, b Brick) bool {
+		if topLevels[a.Id] != topLevels[b.Id] {
+			return topLevels[a.Id] > topLevels[b.Id]
+		}
+		return a.Id < b.Id
+	}
+
+	var plan []DemolitionStep
+	for len(remaining) > 0 {
+		// Find the farthest brick that can be removed without anything falling.
+		best := -1
+		for i, brick := range remaining {
+			if !CanBeSafelyRemoved(brick, graph) {
+				continue
+			}
+			if best == -1 || isFarther(brick, remaining[best]) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return plan, fmt.Errorf("no brick of the remaining %d can be removed safely, the supports contain a cycle", len(remaining))
+		}
+
+		brick := remaining[best]
+		plan = append(plan, DemolitionStep{Id: brick.Id, FallCount: 0, Weakened: append([]int(nil), graph.Supports(brick.Id)...)})
+
+		graph.RemoveBrick(brick.Id)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	return plan, nil
+}
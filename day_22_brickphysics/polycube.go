@@ -0,0 +1,92 @@
+package brickphysics
+
+import (
+	"fmt"
+)
+
+// Offsets of some common polycubes, to be used with NewPolycube.
+var (
+	// An upright bar of three cells with a foot sticking out along X.
+	LShape = []Coordinate{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 0, Y: 0, Z: 1}, {X: 0, Y: 0, Z: 2}}
+	// A bar of three cells along X resting on a stem below its middle cell.
+	TShape = []Coordinate{{X: 1, Y: 0, Z: 0}, {X: 0, Y: 0, Z: 1}, {X: 1, Y: 0, Z: 1}, {X: 2, Y: 0, Z: 1}}
+)
+
+// NewPolycube creates a brick from the cells at the origin plus each of the offsets.
+// The cells have to be unique and connected through their faces. The offsets are
+// copied and moved so the brick starts at the smallest corner of its bounding box.
+func NewPolycube(id int, origin Coordinate, offsets []Coordinate) (Brick, error) {
+	if len(offsets) == 0 {
+		return Brick{}, fmt.Errorf("a polycube needs at least one cell")
+	}
+
+	cells := make(map[Coordinate]bool)
+	minCorner, maxCorner := offsets[0], offsets[0]
+	for _, offset := range offsets {
+		if cells[offset] {
+			return Brick{}, fmt.Errorf("cell %d,%d,%d appears more than once", offset.X, offset.Y, offset.Z)
+		}
+		cells[offset] = true
+		minCorner = Coordinate{X: min(minCorner.X, offset.X), Y: min(minCorner.Y, offset.Y), Z: min(minCorner.Z, offset.Z)}
+		maxCorner = Coordinate{X: max(maxCorner.X, offset.X), Y: max(maxCorner.Y, offset.Y), Z: max(maxCorner.Z, offset.Z)}
+	}
+
+	if !isConnected(cells, offsets[0]) {
+		return Brick{}, fmt.Errorf("the cells are not connected through their faces")
+	}
+
+	normalized := make([]Coordinate, len(offsets))
+	for i, offset := range offsets {
+		normalized[i] = Coordinate{X: offset.X - minCorner.X, Y: offset.Y - minCorner.Y, Z: offset.Z - minCorner.Z}
+	}
+
+	return Brick{
+		Id:      id,
+		Start:   Coordinate{X: origin.X + minCorner.X, Y: origin.Y + minCorner.Y, Z: origin.Z + minCorner.Z},
+		End:     Coordinate{X: origin.X + maxCorner.X, Y: origin.Y + maxCorner.Y, Z: origin.Z + maxCorner.Z},
+		Offsets: normalized,
+	}, nil
+}
+
+// withVoxels returns a copy of the polycube made up of the given, already validated, cells.
+func (this *Brick) withVoxels(voxels []Coordinate) Brick {
+	minCorner, maxCorner := voxels[0], voxels[0]
+	for _, voxel := range voxels {
+		minCorner = Coordinate{X: min(minCorner.X, voxel.X), Y: min(minCorner.Y, voxel.Y), Z: min(minCorner.Z, voxel.Z)}
+		maxCorner = Coordinate{X: max(maxCorner.X, voxel.X), Y: max(maxCorner.Y, voxel.Y), Z: max(maxCorner.Z, voxel.Z)}
+	}
+
+	offsets := make([]Coordinate, len(voxels))
+	for i, voxel := range voxels {
+		offsets[i] = Coordinate{X: voxel.X - minCorner.X, Y: voxel.Y - minCorner.Y, Z: voxel.Z - minCorner.Z}
+	}
+
+	moved := *this
+	moved.Start, moved.End, moved.Offsets = minCorner, maxCorner, offsets
+	return moved
+}
+
+// Helper function to check if all cells can be reached from the first one through shared faces.
+func isConnected(cells map[Coordinate]bool, first Coordinate) bool {
+	reached := map[Coordinate]bool{first: true}
+	queue := []Coordinate{first}
+
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+
+		neighbours := []Coordinate{
+			{X: cell.X - 1, Y: cell.Y, Z: cell.Z}, {X: cell.X + 1, Y: cell.Y, Z: cell.Z},
+			{X: cell.X, Y: cell.Y - 1, Z: cell.Z}, {X: cell.X, Y: cell.Y + 1, Z: cell.Z},
+			{X: cell.X, Y: cell.Y, Z: cell.Z - 1}, {X: cell.X, Y: cell.Y, Z: cell.Z + 1},
+		}
+		for _, neighbour := range neighbours {
+			if cells[neighbour] && !reached[neighbour] {
+				reached[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	return len(reached) == len(cells)
+}
@@ -0,0 +1,6 @@
+//go:build !bricksdebug
+
+package brickphysics
+
+// Regular builds trust the settling engine, build with -tags bricksdebug to check every settled stack.
+const checkInvariants = false
@@ -0,0 +1,92 @@
+package brickphysics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// BrickCriticality describes how much the rest of a settled stack depends on a single brick.
+type BrickCriticality struct {
+	Id            int  `json:"id"`
+	Line          int  `json:"line"`
+	Safe          bool `json:"safe"`          // Whether removing the brick makes no other brick fall.
+	Supporters    int  `json:"supporters"`    // The number of bricks it rests on, not counting the floor.
+	Supported     int  `json:"supported"`     // The number of bricks resting on it.
+	ChainReaction int  `json:"chainReaction"` // The number of bricks falling when it is removed.
+	FallDistance  int  `json:"fallDistance"`  // The levels dropped by all those bricks together.
+	Depth         int  `json:"depth"`         // The number of levels between the brick and the floor.
+}
+
+// CriticalityReport describes every brick of the settled stack, in id order.
+// The fall distances come from removing every brick on a pool of workers, see CountFallsInParallel.
+func (this Physics) CriticalityReport(settledBricks []Brick, graph *SupportGraph, workers int) ([]BrickCriticality, error) {
+	order := graph.topologicalOrder(settledBricks)
+	if len(order) != len(settledBricks) {
+		return nil, fmt.Errorf("the support graph has a cycle, %d of %d bricks can be ordered", len(order), len(settledBricks))
+	}
+
+	chainReactions := ComputeChainReactions(settledBricks, graph)
+	fallDistances := this.measureRemovalsInParallel(settledBricks, workers, func(physics Physics, id int) int {
+		_, fallen, _ := physics.WhatIfRemoved(settledBricks, []int{id})
+
+		distance := 0
+		for _, brick := range fallen {
+			distance += brick.Distance()
+		}
+		return distance
+	})
+
+	report := make([]BrickCriticality, len(settledBricks))
+	for i, brick := range settledBricks {
+		report[i] = BrickCriticality{
+			Id:            brick.Id,
+			Line:          brick.Line,
+			Safe:          CanBeSafelyRemoved(brick, graph),
+			Supporters:    len(removeId(graph.SupportedBy(brick.Id), FloorId)),
+			Supported:     len(graph.Supports(brick.Id)),
+			ChainReaction: chainReactions.FallCount(brick.Id),
+			FallDistance:  fallDistances[brick.Id],
+			Depth:         this.toFrame(brick).Start.Z - this.frameFloor(),
+		}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Id < report[j].Id })
+
+	return report, nil
+}
+
+// WriteCriticalityCsv writes the report as CSV with a header row.
+func WriteCriticalityCsv(w io.Writer, report []BrickCriticality) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "line", "safe", "supporters", "supported", "chain_reaction", "fall_distance", "depth"})
+
+	for _, brick := range report {
+		safe := "no"
+		if brick.Safe {
+			safe = "yes"
+		}
+		writer.Write([]string{
+			strconv.Itoa(brick.Id),
+			strconv.Itoa(brick.Line),
+			safe,
+			strconv.Itoa(brick.Supporters),
+			strconv.Itoa(brick.Supported),
+			strconv.Itoa(brick.ChainReaction),
+			strconv.Itoa(brick.FallDistance),
+			strconv.Itoa(brick.Depth),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCriticalityJson writes the report as an indented JSON array.
+func WriteCriticalityJson(w io.Writer, report []BrickCriticality) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,200 @@
+package brickphysics
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// The lowest Z level a brick can rest at, the ground itself is at Z 0.
+const DefaultFloor = 1
+
+// Physics holds the settings used to let the bricks fall.
+type Physics struct {
+	Floor    int             // The level along the gravity axis the bricks come to rest at.
+	Gravity  Gravity         // The direction the bricks fall in, -Z when not set.
+	Recorder FallRecorder    // Receives every move of a brick when set.
+	Index    *OccupancyIndex // Kept up to date with where every brick lands when set.
+}
+
+// NewPhysics returns the physics of the puzzle, with the bricks falling down to DefaultFloor.
+func NewPhysics() Physics {
+	return Physics{Floor: DefaultFloor, Gravity: NegativeZ}
+}
+
+// SimulateFall lets the bricks fall along the gravity axis until they rest on the floor or on another brick.
+// The bricks are dropped onto a Stack from the floor up, so every brick lands in a single pass, and
+// they are returned in the order they were dropped in, which is the order they came to rest in.
+// X and Y are compressed while settling, so memory grows with the bricks rather than the footprint.
+// Next to the settled bricks it returns how many bricks moved and the ids of the bricks each brick came to rest on.
+// Bricks starting beyond the floor would fall the wrong way, those are reported as an error.
+func (this Physics) SimulateFall(bricks []Brick) ([]Brick, int, map[int][]int, error) {
+	if err := this.CheckFloor(bricks); err != nil {
+		return nil, 0, nil, err
+	}
+
+	// Order the bricks by their distance to the floor.
+	levels := make(map[int]int)
+	for _, brick := range bricks {
+		levels[brick.Id] = this.toFrame(brick).Start.Z
+	}
+	sort.Slice(bricks, func(i, j int) bool {
+		return levels[bricks[i].Id] < levels[bricks[j].Id]
+	})
+
+	// Settle the bricks with their X and Y compressed, falling only changes their Z.
+	framed := make([]Brick, len(bricks))
+	compressed := make([]Brick, len(bricks))
+	for i := range bricks {
+		framed[i] = this.toFrame(bricks[i])
+	}
+	compression := newCompression(framed, false)
+	for i := range framed {
+		compressed[i] = compression.compress(framed[i])
+	}
+
+	stack := this.NewStack()
+	supporters := make(map[int][]int)
+	fallCount := 0
+
+	order := dropOrder(compressed)
+	for _, i := range order {
+		landed, lowerIDs := stack.drop(compressed[i], compressed[i].Voxels())
+		if lowerIDs != nil {
+			supporters[bricks[i].Id] = lowerIDs
+		}
+
+		if fallDistance := compressed[i].Start.Z - landed.Start.Z; fallDistance != 0 {
+			framed[i].Start.Z -= fallDistance
+			framed[i].End.Z -= fallDistance
+			bricks[i] = this.fromFrame(framed[i])
+			fallCount++
+		}
+		if this.Index != nil {
+			this.Index.Place(bricks[i])
+		}
+	}
+
+	// Only polycubes can be dropped out of the order of their distance to the floor.
+	dropped := make([]Brick, len(bricks))
+	for position, i := range order {
+		dropped[position] = bricks[i]
+	}
+	copy(bricks, dropped)
+
+	// What-if removals settle the remaining bricks through here as well, so they are checked too.
+	if checkInvariants {
+		if err := this.CheckSettled(bricks); err != nil {
+			panic(fmt.Sprintf("settled stack breaks its invariants:\n%v", err))
+		}
+	}
+
+	return bricks, fallCount, supporters, nil
+}
+
+// dropOrder returns the order to drop the bricks in, so every brick comes after the bricks below it in a shared column.
+// The bricks have to be ordered by their bottom Z already, which is all it takes for boxes. A polycube however can
+// reach below a brick that starts lower, so then the earliest brick with nothing left below it is dropped next.
+// Polycubes interlocking each other can't be ordered, those are dropped by their bottom Z.
+func dropOrder(bricks []Brick) []int {
+	order := make([]int, len(bricks))
+	for i := range order {
+		order[i] = i
+	}
+
+	hasPolycubes := false
+	for i := range bricks {
+		if !bricks[i].IsBox() {
+			hasPolycubes = true
+			break
+		}
+	}
+	if !hasPolycubes {
+		return order
+	}
+
+	// Link every brick to the next brick above it in each of its columns.
+	type columnCell struct {
+		z     int
+		index int
+	}
+	columns := make(map[Point][]columnCell)
+	for i := range bricks {
+		for _, voxel := range bricks[i].Voxels() {
+			point := Point{X: voxel.X, Y: voxel.Y}
+			columns[point] = append(columns[point], columnCell{z: voxel.Z, index: i})
+		}
+	}
+
+	above := make([]map[int]bool, len(bricks))
+	waitingFor := make([]int, len(bricks))
+	for _, column := range columns {
+		sort.Slice(column, func(i, j int) bool { return column[i].z < column[j].z })
+		for i := 1; i < len(column); i++ {
+			lower, upper := column[i-1].index, column[i].index
+			if lower == upper {
+				continue
+			}
+			if above[lower] == nil {
+				above[lower] = make(map[int]bool)
+			}
+			if !above[lower][upper] {
+				above[lower][upper] = true
+				waitingFor[upper]++
+			}
+		}
+	}
+
+	// Repeatedly drop the earliest brick that is not waiting for anything, or the earliest brick when all are.
+	order = order[:0]
+	dropped := make([]bool, len(bricks))
+	ready := &indexHeap{}
+	for i := range bricks {
+		if waitingFor[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	for next := 0; len(order) < len(bricks); {
+		var i int
+		if ready.Len() > 0 {
+			i = heap.Pop(ready).(int)
+		} else {
+			for dropped[next] {
+				next++
+			}
+			i = next
+		}
+		if dropped[i] {
+			continue
+		}
+
+		dropped[i] = true
+		order = append(order, i)
+		for upper := range above[i] {
+			waitingFor[upper]--
+			if waitingFor[upper] == 0 && !dropped[upper] {
+				heap.Push(ready, upper)
+			}
+		}
+	}
+
+	return order
+}
+
+// indexHeap is a min-heap of slice indices.
+type indexHeap []int
+
+func (this indexHeap) Len() int           { return len(this) }
+func (this indexHeap) Less(i, j int) bool { return this[i] < this[j] }
+func (this indexHeap) Swap(i, j int)      { this[i], this[j] = this[j], this[i] }
+
+func (this *indexHeap) Push(value any) {
+	*this = append(*this, value.(int))
+}
+
+func (this *indexHeap) Pop() any {
+	old := *this
+	value := old[len(old)-1]
+	*this = old[:len(old)-1]
+	return value
+}
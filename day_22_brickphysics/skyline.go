@@ -0,0 +1,165 @@
+package brickphysics
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// Skyline is the top surface of a stack seen from above: the highest Z and the brick owning it per (x, y) point.
+// It spans the footprint of the bricks or the bounds given to NewSkylineIn, points no brick covers have a height of 0 and owner FloorId.
+type Skyline struct {
+	MinX, MinY    int
+	Width, Height int
+	heights       []int
+	owners        []int
+}
+
+// The most points a skyline can hold, 4096 by 4096, so a sparse or huge footprint can't use up the memory.
+const MaxSkylinePoints = 1 << 24
+
+// Footprint returns the X and Y bounds of the bricks seen from above, with the maximum exclusive.
+func Footprint(bricks []Brick) image.Rectangle {
+	if len(bricks) == 0 {
+		return image.Rectangle{}
+	}
+
+	minX, minY, maxX, maxY := bricks[0].Start.X, bricks[0].Start.Y, bricks[0].End.X, bricks[0].End.Y
+	for _, brick := range bricks {
+		minX, minY = min(minX, brick.Start.X), min(minY, brick.Start.Y)
+		maxX, maxY = max(maxX, brick.End.X), max(maxY, brick.End.Y)
+	}
+	return image.Rect(minX, minY, maxX+1, maxY+1)
+}
+
+// NewSkyline computes the skyline of the bricks over their footprint, see NewSkylineIn.
+func NewSkyline(bricks []Brick) (*Skyline, error) {
+	return NewSkylineIn(bricks, Footprint(bricks))
+}
+
+// NewSkylineIn computes the skyline of the bricks over the given X and Y bounds, with the maximum exclusive.
+// Fixing the bounds lets the images of different stacks line up, bricks sticking out of them are an error.
+// So are bounds holding more than MaxSkylinePoints points.
+func NewSkylineIn(bricks []Brick, bounds image.Rectangle) (*Skyline, error) {
+	bounds = bounds.Canon()
+	if bounds.Dx() > 0 && bounds.Dy() > MaxSkylinePoints/bounds.Dx() {
+		return nil, fmt.Errorf("the skyline bounds %v hold more than %d points", bounds, MaxSkylinePoints)
+	}
+	skyline := &Skyline{
+		MinX:    bounds.Min.X,
+		MinY:    bounds.Min.Y,
+		Width:   bounds.Dx(),
+		Height:  bounds.Dy(),
+		heights: make([]int, bounds.Dx()*bounds.Dy()),
+		owners:  make([]int, bounds.Dx()*bounds.Dy()),
+	}
+
+	for _, brick := range bricks {
+		if !image.Rect(brick.Start.X, brick.Start.Y, brick.End.X+1, brick.End.Y+1).In(bounds) {
+			return nil, fmt.Errorf("brick %d (%s) lies outside the skyline bounds %v", brick.Id, brick.ToString(), bounds)
+		}
+		for _, box := range brick.boxes() {
+			for x := box[0].X; x <= box[1].X; x++ {
+				for y := box[0].Y; y <= box[1].Y; y++ {
+					i := skyline.offset(x, y)
+					if box[1].Z > skyline.heights[i] {
+						skyline.heights[i], skyline.owners[i] = box[1].Z, brick.Id
+					}
+				}
+			}
+		}
+	}
+
+	return skyline, nil
+}
+
+// Helper function to get the position of a point in the heights and owners.
+func (this *Skyline) offset(x, y int) int {
+	return (y-this.MinY)*this.Width + (x - this.MinX)
+}
+
+// At returns the highest Z at the point and the id of the brick owning it, or 0 and FloorId for an uncovered point.
+func (this *Skyline) At(x, y int) (int, int) {
+	if x < this.MinX || x >= this.MinX+this.Width || y < this.MinY || y >= this.MinY+this.Height {
+		return 0, FloorId
+	}
+	i := this.offset(x, y)
+	return this.heights[i], this.owners[i]
+}
+
+// Helper function to map a point to a pixel, with X to the right and Y up like the top view of WriteSvg.
+func (this *Skyline) pixel(x, y int) (int, int) {
+	return x - this.MinX, this.MinY + this.Height - 1 - y
+}
+
+// HeightImage returns the skyline as a grayscale image with the highest Z of every point as its value,
+// so the images of different stacks with the same bounds can be compared pixel by pixel.
+// A height that doesn't fit in 16 bits is an error.
+func (this *Skyline) HeightImage() (*image.Gray16, error) {
+	return this.grayImage(this.heights, "height")
+}
+
+// OwnerImage returns the skyline as an image with the colour of the brick owning every point, see BrickColor.
+// Uncovered points are left transparent.
+func (this *Skyline) OwnerImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, this.Width, this.Height))
+	for y := this.MinY; y < this.MinY+this.Height; y++ {
+		for x := this.MinX; x < this.MinX+this.Width; x++ {
+			if owner := this.owners[this.offset(x, y)]; owner != FloorId {
+				px, py := this.pixel(x, y)
+				img.SetRGBA(px, py, BrickColor(owner))
+			}
+		}
+	}
+	return img
+}
+
+// OwnerIdImage returns the skyline as a grayscale image with the id of the brick owning every point as its value.
+// An id that doesn't fit in 16 bits is an error.
+func (this *Skyline) OwnerIdImage() (*image.Gray16, error) {
+	return this.grayImage(this.owners, "owner id")
+}
+
+// Helper function to turn the values of every point into a grayscale image, refusing values that would wrap around.
+func (this *Skyline) grayImage(values []int, name string) (*image.Gray16, error) {
+	img := image.NewGray16(image.Rect(0, 0, this.Width, this.Height))
+	for y := this.MinY; y < this.MinY+this.Height; y++ {
+		for x := this.MinX; x < this.MinX+this.Width; x++ {
+			value := values[this.offset(x, y)]
+			if value < 0 || value > math.MaxUint16 {
+				return nil, fmt.Errorf("%s %d at (%d, %d) doesn't fit in a 16 bit image", name, value, x, y)
+			}
+			px, py := this.pixel(x, y)
+			img.SetGray16(px, py, color.Gray16{Y: uint16(value)})
+		}
+	}
+	return img, nil
+}
+
+// WritePgm writes the image as a binary PGM, using a single byte per pixel when every value fits.
+// The maximum value of the file is the highest value in the image, so the values are written unscaled.
+func WritePgm(w io.Writer, img *image.Gray16) error {
+	bounds := img.Bounds()
+	maxValue := 1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			maxValue = max(maxValue, int(img.Gray16At(x, y).Y))
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "P5\n%d %d\n%d\n", bounds.Dx(), bounds.Dy(), maxValue)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			value := img.Gray16At(x, y).Y
+			if maxValue > 255 {
+				writer.WriteByte(byte(value >> 8))
+			}
+			writer.WriteByte(byte(value))
+		}
+	}
+	return writer.Flush()
+}
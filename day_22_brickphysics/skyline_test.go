@@ -0,0 +1,57 @@
+package brickphysics
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSkylineImagesRejectValuesBeyond16Bits(t *testing.T) {
+	tall := Brick{Id: 1, Start: Coordinate{X: 0, Y: 0, Z: 1}, End: Coordinate{X: 0, Y: 0, Z: 70000}}
+	skyline, err := NewSkyline([]Brick{tall})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := skyline.HeightImage(); err == nil {
+		t.Fatalf("HeightImage wrapped around the height of brick %s", tall.ToString())
+	}
+
+	numerous := Brick{Id: 70000, Start: Coordinate{X: 0, Y: 0, Z: 1}, End: Coordinate{X: 0, Y: 0, Z: 1}}
+	if skyline, err = NewSkyline([]Brick{numerous}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := skyline.OwnerIdImage(); err == nil {
+		t.Fatalf("OwnerIdImage wrapped around the id of brick %d", numerous.Id)
+	}
+}
+
+func TestSkylineInKeepsTheOrigin(t *testing.T) {
+	bricks := []Brick{{Id: 1, Start: Coordinate{X: 2, Y: 1, Z: 1}, End: Coordinate{X: 3, Y: 1, Z: 4}}}
+
+	skyline, err := NewSkylineIn(bricks, image.Rect(0, 0, 5, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skyline.MinX != 0 || skyline.MinY != 0 || skyline.Width != 5 || skyline.Height != 5 {
+		t.Fatalf("skyline spans %d,%d with size %dx%d, expected 0,0 with size 5x5", skyline.MinX, skyline.MinY, skyline.Width, skyline.Height)
+	}
+	if z, owner := skyline.At(3, 1); z != 4 || owner != 1 {
+		t.Fatalf("skyline at 3,1 is Z %d owned by %d, expected Z 4 owned by 1", z, owner)
+	}
+
+	if _, err := NewSkylineIn(bricks, image.Rect(0, 0, 3, 3)); err == nil {
+		t.Fatalf("NewSkylineIn accepted brick %s sticking out of the bounds", bricks[0].ToString())
+	}
+}
+
+func TestSkylineRejectsTooManyPoints(t *testing.T) {
+	bricks := []Brick{
+		{Id: 1, Start: Coordinate{X: 0, Y: 0, Z: 1}, End: Coordinate{X: 0, Y: 0, Z: 1}},
+		{Id: 2, Start: Coordinate{X: 5000000, Y: 5000000, Z: 1}, End: Coordinate{X: 5000000, Y: 5000000, Z: 1}},
+	}
+	if _, err := NewSkyline(bricks); err == nil {
+		t.Fatal("NewSkyline accepted a footprint of 5000001 by 5000001 points")
+	}
+	if _, err := NewSkylineIn(nil, image.Rect(0, 0, 1000000, 1000000)); err == nil {
+		t.Fatal("NewSkylineIn accepted bounds of 1000000 by 1000000 points")
+	}
+}
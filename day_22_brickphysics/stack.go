@@ -0,0 +1,211 @@
+package brickphysics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An occupied cell in a column of the stack, holding its Z and the brick occupying it.
+type HeightCell struct {
+	Z       int
+	BrickId int
+}
+
+// Stack is a settled stack of bricks that new bricks can be dropped onto one at a time, Tetris-style.
+// The bricks are kept in the frame where they fall along -Z, whatever the gravity of the physics.
+// It keeps the occupied cells of every (x, y) column, so every brick lands in a single pass. Drop keys
+// the columns by the slabs between the X and Y values where bricks start and end, splitting them as
+// new bricks arrive, so a huge brick takes a handful of columns rather than one per point it covers.
+// Looking below each cell of a brick, rather than only at the top of each column, lets polycubes
+// land in the gaps below the overhangs of other polycubes. When the physics have a Recorder, every
+// level a brick drops is still reported as a separate move. When they have an Index, every brick
+// is placed in it where it landed.
+type Stack struct {
+	physics Physics
+	columns map[Point][]HeightCell // The occupied cells of each column, ordered by Z.
+	slabs   [2][]int               // The ordered X and Y values the columns of Drop start at.
+	bricks  []Brick
+	graph   *SupportGraph
+	tick    int
+}
+
+// NewStack returns an empty stack using these physics.
+func (this Physics) NewStack() *Stack {
+	return &Stack{physics: this, columns: make(map[Point][]HeightCell), graph: NewSupportGraph(nil)}
+}
+
+// Drop lets the brick fall along the gravity axis onto the stack and adds it to the stack.
+// The brick falls from its starting position until any of its cells hits something.
+// It returns the level along the gravity axis the brick landed at and the ids of the bricks it rests on,
+// which include FloorId for a polycube resting on both the floor and other bricks.
+// A brick starting beyond the floor would fall the wrong way, and a brick overlapping the stack or starting
+// below one of its cells would end up inside it. Both are reported as an error and leave the stack as it was,
+// so the bricks have to arrive in order of their distance to the floor.
+func (this *Stack) Drop(brick Brick) (int, []int, error) {
+	if err := this.physics.CheckFloor([]Brick{brick}); err != nil {
+		return 0, nil, err
+	}
+	framed := this.physics.toFrame(brick)
+	cells := this.slabCells(framed)
+	for _, voxel := range cells {
+		column := this.columns[Point{X: voxel.X, Y: voxel.Y}]
+		if len(column) > 0 && column[len(column)-1].Z >= voxel.Z {
+			return 0, nil, fmt.Errorf("brick %d (%s) arrives at or below brick %d of the stack", brick.Id, brick.ToString(), column[len(column)-1].BrickId)
+		}
+	}
+
+	landed, supporters := this.drop(framed, cells)
+	if this.physics.Index != nil {
+		this.physics.Index.Place(this.physics.fromFrame(landed))
+	}
+
+	if checkInvariants {
+		if err := this.physics.CheckSettled(this.Bricks()); err != nil {
+			panic(fmt.Sprintf("stack breaks its invariants after dropping brick %d:\n%v", brick.Id, err))
+		}
+	}
+	return this.physics.worldLevel(landed.Start.Z), supporters, nil
+}
+
+// slabCells returns the cells of a brick in the settling frame, with one cell per slab it covers in X and Y.
+// The slab boundaries of the brick are added first, splitting the columns they cut through.
+func (this *Stack) slabCells(brick Brick) []Coordinate {
+	boxes := brick.boxes()
+	for _, box := range boxes {
+		this.addSlab(0, box[0].X)
+		this.addSlab(0, box[1].X+1)
+		this.addSlab(1, box[0].Y)
+		this.addSlab(1, box[1].Y+1)
+	}
+
+	var cells []Coordinate
+	for _, box := range boxes {
+		for i := sort.SearchInts(this.slabs[0], box[0].X); this.slabs[0][i] <= box[1].X; i++ {
+			for j := sort.SearchInts(this.slabs[1], box[0].Y); this.slabs[1][j] <= box[1].Y; j++ {
+				for z := box[0].Z; z <= box[1].Z; z++ {
+					cells = append(cells, Coordinate{X: this.slabs[0][i], Y: this.slabs[1][j], Z: z})
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// addSlab starts a new slab at the value along the X (0) or Y (1) axis. The columns of the slab it
+// splits are copied into it, as both halves hold the same cells.
+func (this *Stack) addSlab(axis int, value int) {
+	slabs := this.slabs[axis]
+	i := sort.SearchInts(slabs, value)
+	if i < len(slabs) && slabs[i] == value {
+		return
+	}
+	this.slabs[axis] = append(slabs[:i], append([]int{value}, slabs[i:]...)...)
+	if i == 0 {
+		return
+	}
+
+	for _, other := range this.slabs[1-axis] {
+		from, to := Point{X: slabs[i-1], Y: other}, Point{X: value, Y: other}
+		if axis == 1 {
+			from, to = Point{X: other, Y: slabs[i-1]}, Point{X: other, Y: value}
+		}
+		if column, found := this.columns[from]; found {
+			this.columns[to] = append([]HeightCell(nil), column...)
+		}
+	}
+}
+
+// drop lets a brick in the settling frame fall along -Z and returns where it landed and the bricks it rests on.
+// The voxels are the cells of the brick in the columns of the stack.
+func (this *Stack) drop(brick Brick, voxels []Coordinate) (Brick, []int) {
+
+	// Find the lowest level the brick can fall to before one of its cells hits an occupied cell.
+	restZ := this.physics.frameFloor() - 1
+	for _, voxel := range voxels {
+		if cell, found := this.cellBelow(Point{X: voxel.X, Y: voxel.Y}, voxel.Z); found {
+			restZ = max(restZ, cell.Z-(voxel.Z-brick.Start.Z))
+		}
+	}
+
+	// The bricks owning a cell directly below one of the landed cells are the supporters.
+	var supporters []int
+	seen := make(map[int]bool)
+	for _, voxel := range voxels {
+		landedZ := restZ + 1 + voxel.Z - brick.Start.Z
+		if cell, found := this.cellAt(Point{X: voxel.X, Y: voxel.Y}, landedZ-1); found && !seen[cell.BrickId] {
+			seen[cell.BrickId] = true
+			supporters = append(supporters, cell.BrickId)
+		}
+	}
+	sort.Ints(supporters)
+
+	// A polycube can rest on the floor and on other bricks at the same time. The floor is then recorded
+	// as a supporter too, so none of those bricks is taken for its only support.
+	if len(supporters) > 0 && restZ+1 == this.physics.frameFloor() {
+		supporters = append([]int{FloorId}, supporters...)
+	}
+
+	// Drop the brick on top of the highest level and claim its cells.
+	fallDistance := brick.Start.Z - (restZ + 1)
+	if this.physics.Recorder != nil {
+		for fromZ := brick.Start.Z; fromZ > restZ+1; fromZ-- {
+			this.tick++
+			this.physics.Recorder.Record(FallEvent{Tick: this.tick, BrickId: brick.Id, FromZ: this.physics.worldLevel(fromZ), ToZ: this.physics.worldLevel(fromZ - 1)})
+		}
+	}
+	brick.Start.Z -= fallDistance
+	brick.End.Z -= fallDistance
+
+	for _, voxel := range voxels {
+		this.claim(Point{X: voxel.X, Y: voxel.Y}, HeightCell{Z: voxel.Z - fallDistance, BrickId: brick.Id})
+	}
+
+	this.bricks = append(this.bricks, brick)
+	this.graph.addBrick(brick.Id, supporters)
+
+	return brick, supporters
+}
+
+// cellBelow returns the highest occupied cell of the column below the given Z.
+func (this *Stack) cellBelow(point Point, z int) (HeightCell, bool) {
+	column := this.columns[point]
+	i := sort.Search(len(column), func(i int) bool { return column[i].Z >= z })
+	if i == 0 {
+		return HeightCell{}, false
+	}
+	return column[i-1], true
+}
+
+// cellAt returns the cell of the column at the given Z, if it is occupied.
+func (this *Stack) cellAt(point Point, z int) (HeightCell, bool) {
+	column := this.columns[point]
+	i := sort.Search(len(column), func(i int) bool { return column[i].Z >= z })
+	if i == len(column) || column[i].Z != z {
+		return HeightCell{}, false
+	}
+	return column[i], true
+}
+
+// claim marks a cell of the column as occupied, keeping the column ordered by Z.
+func (this *Stack) claim(point Point, cell HeightCell) {
+	column := this.columns[point]
+	i := sort.Search(len(column), func(i int) bool { return column[i].Z >= cell.Z })
+	column = append(column, HeightCell{})
+	copy(column[i+1:], column[i:])
+	column[i] = cell
+	this.columns[point] = column
+}
+
+// Bricks returns a snapshot of the bricks in the stack, in the order they were dropped.
+func (this *Stack) Bricks() []Brick {
+	bricks := make([]Brick, len(this.bricks))
+	for i, brick := range this.bricks {
+		bricks[i] = this.physics.fromFrame(brick)
+	}
+	return bricks
+}
+
+// Graph returns the support graph of the stack, it is kept up to date by every Drop and should not be changed.
+func (this *Stack) Graph() *SupportGraph {
+	return this.graph
+}
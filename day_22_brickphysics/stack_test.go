@@ -0,0 +1,85 @@
+package brickphysics
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDropRejectsBricksArrivingInsideTheStack(t *testing.T) {
+	stack := NewPhysics().NewStack()
+	if _, _, err := stack.Drop(Brick{Id: 1, Start: Coordinate{X: 1, Y: 0, Z: 5}, End: Coordinate{X: 1, Y: 2, Z: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := stack.Drop(Brick{Id: 2, Start: Coordinate{X: 1, Y: 0, Z: 1}, End: Coordinate{X: 1, Y: 2, Z: 1}}); err == nil {
+		t.Fatalf("brick 2 was dropped below brick 1, into %v", stack.Bricks())
+	}
+	if bricks := stack.Bricks(); len(bricks) != 1 {
+		t.Fatalf("the rejected brick was added to the stack: %v", bricks)
+	}
+}
+
+func TestDropKeepsOutOfOrderArrivalsSettled(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader(exampleInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	physics := NewPhysics()
+
+	// Arriving from the top down, every brick below an earlier one is rejected and the rest stays settled.
+	stack := physics.NewStack()
+	for i := len(bricks) - 1; i >= 0; i-- {
+		stack.Drop(bricks[i])
+	}
+	if err := physics.CheckSettled(stack.Bricks()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Arriving from the floor up, the stack settles like SimulateFall does.
+	sort.Slice(bricks, func(i, j int) bool { return bricks[i].Start.Z < bricks[j].Start.Z })
+	stack = physics.NewStack()
+	for _, brick := range bricks {
+		if _, _, err := stack.Drop(brick); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := physics.CheckSettled(stack.Bricks()); err != nil {
+		t.Fatal(err)
+	}
+	if safeCount := CountSafelyRemovableBricks(stack.Bricks(), stack.Graph()); safeCount != 5 {
+		t.Fatalf("%d bricks can be removed safely instead of 5", safeCount)
+	}
+}
+
+func TestDropMatchesSimulateFall(t *testing.T) {
+	config := GeneratorConfig{Width: 6, Depth: 6, MinZ: DefaultFloor, MaxZ: 150, Count: 120, MaxLength: 4, VerticalRatio: 0.2}
+	for seed := int64(1); seed <= 5; seed++ {
+		config.Seed = seed
+		bricks, err := Generate(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(bricks, func(i, j int) bool { return bricks[i].Start.Z < bricks[j].Start.Z })
+
+		stack := NewPhysics().NewStack()
+		for _, brick := range bricks {
+			if _, _, err := stack.Drop(brick); err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+		}
+
+		settled, _, _, err := NewPhysics().SimulateFall(Snapshot(bricks))
+		if err != nil {
+			t.Fatal(err)
+		}
+		landed := make(map[int]string)
+		for _, brick := range stack.Bricks() {
+			landed[brick.Id] = brick.ToString()
+		}
+		for _, brick := range settled {
+			if landed[brick.Id] != brick.ToString() {
+				t.Fatalf("seed %d: brick %d was dropped onto %s instead of %s", seed, brick.Id, landed[brick.Id], brick.ToString())
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package brickphysics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The size in pixels of a single cell in the SVG renderings.
+const svgCellSize = 12
+
+// WriteSvg renders a projection of the bricks as SVG with a stable colour per brick id.
+// The "x" view looks along +Y with X to the right and Z up, the "y" view looks along +X with Y
+// to the right and Z up, and the "z" (or "top") view looks down with X to the right and Y up.
+// Bricks are drawn from far to near so the brick closest to the viewer is the one that shows.
+func WriteSvg(w io.Writer, bricks []Brick, view string, labels bool) error {
+	// The projected rectangle of a brick as horizontal and vertical ranges plus a depth, larger is nearer.
+	type projection struct {
+		brick                         Brick
+		minH, maxH, minV, maxV, depth int
+	}
+
+	// A polycube is projected one cell at a time, a box all at once.
+	projections := make([]projection, 0, len(bricks))
+	for _, brick := range bricks {
+		for _, box := range brick.boxes() {
+			start, end := box[0], box[1]
+			switch strings.ToLower(view) {
+			case "x":
+				projections = append(projections, projection{brick, start.X, end.X, start.Z, end.Z, -start.Y})
+			case "y":
+				projections = append(projections, projection{brick, start.Y, end.Y, start.Z, end.Z, -start.X})
+			case "z", "top":
+				projections = append(projections, projection{brick, start.X, end.X, start.Y, end.Y, end.Z})
+			default:
+				return fmt.Errorf("unknown view %q, expected x, y or z", view)
+			}
+		}
+	}
+
+	// Bricks overlapping in a projection can't overlap in depth, so sorting on depth gives a correct painter's order.
+	sort.SliceStable(projections, func(i, j int) bool {
+		if projections[i].depth != projections[j].depth {
+			return projections[i].depth < projections[j].depth
+		}
+		return projections[i].brick.Id < projections[j].brick.Id
+	})
+
+	minH, maxH, minV, maxV := 0, 0, 0, 0
+	for i, p := range projections {
+		if i == 0 || p.minH < minH {
+			minH = p.minH
+		}
+		if i == 0 || p.maxH > maxH {
+			maxH = p.maxH
+		}
+		if i == 0 || p.minV < minV {
+			minV = p.minV
+		}
+		if i == 0 || p.maxV > maxV {
+			maxV = p.maxV
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	width, height := (maxH-minH+1)*svgCellSize, (maxV-minV+1)*svgCellSize
+	fmt.Fprintf(writer, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(writer, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height)
+
+	for _, p := range projections {
+		c := BrickColor(p.brick.Id)
+		x := (p.minH - minH) * svgCellSize
+		y := (maxV - p.maxV) * svgCellSize // The vertical axis points up, the SVG axis points down.
+		rectWidth, rectHeight := (p.maxH-p.minH+1)*svgCellSize, (p.maxV-p.minV+1)*svgCellSize
+
+		fmt.Fprintf(writer, "<g><title>brick %d: %s</title>", p.brick.Id, p.brick.ToString())
+		fmt.Fprintf(writer, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"#%02x%02x%02x\" stroke=\"black\" stroke-width=\"1\"/>", x, y, rectWidth, rectHeight, c.R, c.G, c.B)
+		if labels {
+			fmt.Fprintf(writer, "<text x=\"%d\" y=\"%d\" font-family=\"monospace\" font-size=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">%d</text>", x+rectWidth/2, y+rectHeight/2, svgCellSize*2/3, p.brick.Id)
+		}
+		fmt.Fprintln(writer, "</g>")
+	}
+
+	fmt.Fprintln(writer, "</svg>")
+	return writer.Flush()
+}
@@ -0,0 +1,74 @@
+package brickphysics
+
+import (
+	"fmt"
+)
+
+// FallenBrick describes a brick that dropped during a what-if simulation.
+// FromZ and ToZ are the levels along the gravity axis, see Physics.Level.
+type FallenBrick struct {
+	Id    int
+	FromZ int
+	ToZ   int
+}
+
+// Distance returns how many levels the brick dropped.
+func (this FallenBrick) Distance() int {
+	if this.FromZ < this.ToZ {
+		return this.ToZ - this.FromZ
+	}
+	return this.FromZ - this.ToZ
+}
+
+// WhatIfRemoved removes the bricks with the given ids from the settled stack and lets the others fall.
+// It returns the resulting stack and the bricks that fell in the order they came to rest,
+// the settled stack itself is left untouched. When the physics have an Index, it ends up holding the resulting stack.
+func (this Physics) WhatIfRemoved(settledBricks []Brick, ids []int) ([]Brick, []FallenBrick, error) {
+	startZ := make(map[int]int)
+	for _, brick := range settledBricks {
+		startZ[brick.Id] = this.Level(brick)
+	}
+	for _, id := range ids {
+		if _, found := startZ[id]; !found {
+			return nil, nil, fmt.Errorf("no brick with id %d in the stack", id)
+		}
+	}
+
+	if this.Index != nil {
+		for _, id := range ids {
+			this.Index.Remove(id)
+		}
+	}
+
+	// SimulateFall returns the bricks in the order they were dropped in, so this is already the landing order.
+	bricks, _, _, err := this.SimulateFall(SnapshotWithout(settledBricks, ids...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fallen []FallenBrick
+	for _, brick := range bricks {
+		if level := this.Level(brick); level != startZ[brick.Id] {
+			fallen = append(fallen, FallenBrick{Id: brick.Id, FromZ: startZ[brick.Id], ToZ: level})
+		}
+	}
+
+	return bricks, fallen, nil
+}
+
+// CountFallsByResimulation removes every brick one at a time and re-runs the fall simulation.
+// This is the slow reference for the chain reactions, returning the fall count per removed brick id.
+// The Index of the physics is left alone, as it can only hold a single stack.
+func (this Physics) CountFallsByResimulation(settledBricks []Brick) map[int]int {
+	this.Index = nil
+	fallCounts := make(map[int]int)
+
+	for _, brick := range settledBricks {
+		// Calculate the bricks that have fallen this simulation. A settled stack has no bricks beyond the
+		// floor, and removing bricks can't add any, so settling it again can't fail.
+		_, fallCount, _, _ := this.SimulateFall(SnapshotWithout(settledBricks, brick.Id))
+		fallCounts[brick.Id] = fallCount
+	}
+
+	return fallCounts
+}
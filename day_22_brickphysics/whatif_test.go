@@ -0,0 +1,95 @@
+package brickphysics
+
+import (
+	"strings"
+	"testing"
+)
+
+// The example stack from the puzzle description.
+const exampleInput = `1,0,1~1,2,1
+0,0,2~2,0,2
+0,2,3~2,2,3
+0,0,4~0,2,4
+2,0,5~2,2,5
+0,1,6~2,1,6
+1,1,8~1,1,9`
+
+// Helper function to settle a stack, failing the test when it can't be settled.
+func settledStack(t *testing.T, bricks []Brick) []Brick {
+	t.Helper()
+	settled, _, _, err := NewPhysics().SimulateFall(bricks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return settled
+}
+
+// Helper function to check repeated what-if removals leave the settled stack as it was.
+func checkWhatIfLeavesStackUnchanged(t *testing.T, settled []Brick, rounds int) {
+	t.Helper()
+	physics := NewPhysics()
+
+	before := make([]string, len(settled))
+	for i := range settled {
+		before[i] = settled[i].ToString()
+	}
+	reference := physics.CountFallsByResimulation(settled)
+
+	for round := 1; round <= rounds; round++ {
+		for _, brick := range Snapshot(settled) {
+			if _, _, err := physics.WhatIfRemoved(settled, []int{brick.Id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, _, err := physics.WhatIfRemoved(settled, []int{settled[0].Id, settled[len(settled)-1].Id}); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range settled {
+			if after := settled[i].ToString(); after != before[i] {
+				t.Fatalf("round %d: settled brick %d moved from %s to %s", round, settled[i].Id, before[i], after)
+			}
+		}
+		for id, fallCount := range physics.CountFallsByResimulation(settled) {
+			if fallCount != reference[id] {
+				t.Fatalf("round %d: removing brick %d made %d bricks fall instead of %d", round, id, fallCount, reference[id])
+			}
+		}
+	}
+}
+
+func TestWhatIfLeavesExampleStackUnchanged(t *testing.T) {
+	bricks, err := ParseBricks(strings.NewReader(exampleInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkWhatIfLeavesStackUnchanged(t, settledStack(t, bricks), 5)
+}
+
+func TestWhatIfLeavesGeneratedStackUnchanged(t *testing.T) {
+	config := GeneratorConfig{Width: 6, Depth: 6, MinZ: DefaultFloor, MaxZ: 150, Count: 120, MaxLength: 4, VerticalRatio: 0.2}
+	for seed := int64(1); seed <= 5; seed++ {
+		config.Seed = seed
+		bricks, err := Generate(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWhatIfLeavesStackUnchanged(t, settledStack(t, bricks), 3)
+	}
+}
+
+func TestSimulateFallReturnsBricksInDropOrder(t *testing.T) {
+	// The hook of brick 1 reaches over brick 2, so brick 2 lands first although it starts higher.
+	bricks, err := ParseBricks(strings.NewReader("0,0,3@0,0,0;0,0,1;0,0,2;1,0,2\n1,0,4~1,0,4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settled, _, _, err := NewPhysics().SimulateFall(bricks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled[0].Id != 2 || settled[1].Id != 1 {
+		t.Fatalf("the bricks came back as %s, %s instead of brick 2 before brick 1", settled[0].ToString(), settled[1].ToString())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/DirkHeijnen/advent-of-code-2023/day_22_brickphysics"
+)
+
+// Helper function to write the bricks to the file, or to stdout for an empty path.
+// The file is closed before returning, so a write failing on close is reported too.
+func writeBricks(path string, bricks []brickphysics.Brick) error {
+	if path == "" {
+		return brickphysics.WriteBricks(os.Stdout, bricks)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := brickphysics.WriteBricks(file, bricks); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Writes a random day 22 input, for fuzzing and benchmarking the brick physics.
+func main() {
+	defaults := brickphysics.NewGeneratorConfig()
+	seed := flag.Int64("seed", defaults.Seed, "the seed of the random generator, the same seed gives the same input")
+	width := flag.Int("width", defaults.Width, "the size of the footprint along X")
+	depth := flag.Int("depth", defaults.Depth, "the size of the footprint along Y")
+	minZ := flag.Int("minz", defaults.MinZ, "the lowest level a brick can start at")
+	maxZ := flag.Int("maxz", defaults.MaxZ, "the highest level a brick can reach")
+	count := flag.Int("count", defaults.Count, "the number of bricks")
+	maxLength := flag.Int("maxlength", defaults.MaxLength, "the longest a brick can be, in cells")
+	vertical := flag.Float64("vertical", defaults.VerticalRatio, "the chance of a brick standing upright, between 0 and 1")
+	outPath := flag.String("out", "", "the file to write the input to, instead of stdout")
+	flag.Parse()
+
+	config := brickphysics.GeneratorConfig{
+		Seed:          *seed,
+		Width:         *width,
+		Depth:         *depth,
+		MinZ:          *minZ,
+		MaxZ:          *maxZ,
+		Count:         *count,
+		MaxLength:     *maxLength,
+		VerticalRatio: *vertical,
+	}
+
+	bricks, err := brickphysics.Generate(config)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if err := writeBricks(*outPath, bricks); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
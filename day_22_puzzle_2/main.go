@@ -2,186 +2,534 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"os"
-	"sort"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/DirkHeijnen/advent-of-code-2023/day_22_brickphysics"
 )
 
-// Helper function to parse a string to an integer.
-func atoi(str string) int {
-	num, err := strconv.Atoi(str)
+// loadBricks reads the bricks from the input and checks they can fall towards the floor of the physics.
+func loadBricks(physics brickphysics.Physics) ([]brickphysics.Brick, error) {
+	bricks, err := brickphysics.ParseFile("input.txt")
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if err := physics.CheckFloor(bricks); err != nil {
+		return nil, err
 	}
-	return num
+	return bricks, nil
 }
 
-func replaceAt(str string, replacement string, index int) string {
-	return str[:index] + replacement + str[index+len(replacement):]
+// printWhatIfRemoved prints which bricks fall, and how far, when the given bricks are removed from the settled stack.
+func printWhatIfRemoved(physics brickphysics.Physics, ids []int) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+	settledBricks, _, _, err := physics.SimulateFall(bricks)
+	if err != nil {
+		return err
+	}
+
+	_, fallen, err := physics.WhatIfRemoved(settledBricks, ids)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing %d brick(s) makes %d brick(s) fall\n", len(ids), len(fallen))
+	for i, brick := range fallen {
+		fmt.Printf("%d. brick %d drops %d level(s), from Z %d to Z %d\n", i+1, brick.Id, brick.Distance(), brick.FromZ, brick.ToZ)
+	}
+
+	return nil
 }
 
-func parseInput() []Brick {
-	// Open file
-	file, err := os.Open("input.txt")
+// printLoads prints the load every brick of the settled stack bears, or of the stack left after removing the given bricks.
+// Bricks bearing more than the capacity are flagged, when the capacity is above zero.
+func printLoads(physics brickphysics.Physics, removeIds []int, capacity float64) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+	settledBricks, _, _, err := physics.SimulateFall(bricks)
 	if err != nil {
-		fmt.Println("Error opening file", err)
-		panic(err)
+		return err
 	}
 
-	defer file.Close()
+	if len(removeIds) > 0 {
+		if settledBricks, _, err = physics.WhatIfRemoved(settledBricks, removeIds); err != nil {
+			return err
+		}
+	}
 
-	// Keep a list of bricks.
-	var bricks []Brick
-	var nextID int = 1
+	// Settling a settled stack again moves nothing, but gives the supporters of every brick.
+	settledBricks, _, supporters, err := physics.SimulateFall(settledBricks)
+	if err != nil {
+		return err
+	}
+	report, err := brickphysics.LoadReport(settledBricks, brickphysics.NewSupportGraph(supporters), capacity)
+	if err != nil {
+		return err
+	}
 
-	// Handle lines
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		// Read the line and split on "~"
-		line := scanner.Text()
-		parts := strings.Split(line, "~")
+	overloaded := 0
+	for _, load := range report {
+		note := ""
+		if load.Overloaded {
+			note = " OVERLOADED"
+			overloaded++
+		}
+		fmt.Printf("Brick %d (line %d): weight %d, bears %.2f%s\n", load.Id, load.Line, load.Weight, load.Load, note)
+	}
+	if capacity > 0 {
+		fmt.Printf("%d brick(s) bear more than %g\n", overloaded, capacity)
+	}
 
-		// Read part 1 to get start position and part 2 to get end position.
-		startPosition := strings.Split(parts[0], ",")
-		endPosition := strings.Split(parts[1], ",")
+	return nil
+}
 
-		// Create the coordinate instances
-		startCoordinate := Coordinate{X: atoi(startPosition[0]), Y: atoi(startPosition[1]), Z: atoi(startPosition[2])}
-		endCoordinate := Coordinate{X: atoi(endPosition[0]), Y: atoi(endPosition[1]), Z: atoi(endPosition[2])}
+// writeCriticalityReport writes the criticality of every brick of the settled stack to the file,
+// as CSV or JSON depending on its extension.
+func writeCriticalityReport(physics brickphysics.Physics, path string, workers int) error {
+	var write func(w io.Writer, report []brickphysics.BrickCriticality) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		write = brickphysics.WriteCriticalityCsv
+	case ".json":
+		write = brickphysics.WriteCriticalityJson
+	default:
+		return fmt.Errorf("unknown report format %q, expected .csv or .json", filepath.Ext(path))
+	}
 
-		// Append the brick.
-		bricks = append(bricks, Brick{Id: nextID, Start: &startCoordinate, End: &endCoordinate})
-		nextID++
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+	settledBricks, _, supporters, err := physics.SimulateFall(bricks)
+	if err != nil {
+		return err
 	}
 
-	// Handle file reading error.
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading from file:", err)
+	report, err := physics.CriticalityReport(settledBricks, brickphysics.NewSupportGraph(supporters), workers)
+	if err != nil {
+		return err
 	}
 
-	return bricks
+	return writeFile(path, func(w io.Writer) error {
+		return write(w, report)
+	})
 }
 
-type Coordinate struct {
-	X int
-	Y int
-	Z int
+// printEarthquakes removes the given number of random bricks from the settled stack at once, over and over,
+// and prints the distribution of the number of bricks that fell.
+func printEarthquakes(physics brickphysics.Physics, config brickphysics.EarthquakeConfig) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+	settledBricks, _, _, err := physics.SimulateFall(bricks)
+	if err != nil {
+		return err
+	}
+
+	earthquakes, err := physics.SimulateEarthquakes(settledBricks, config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing %d random brick(s) at once in %d trials (seed %d)\n", config.Removed, config.Trials, config.Seed)
+	fmt.Printf("Mean collapse: %.2f brick(s)\n", earthquakes.Mean())
+	fmt.Printf("Percentiles: p50 %d, p90 %d, p99 %d, max %d\n", earthquakes.Percentile(50), earthquakes.Percentile(90), earthquakes.Percentile(99), earthquakes.Percentile(100))
+
+	// Scale the bars so the largest bucket is 50 characters wide.
+	histogram := earthquakes.Histogram(10)
+	largest := 0
+	for _, bucket := range histogram {
+		largest = max(largest, bucket.Count)
+	}
+	for _, bucket := range histogram {
+		bar := strings.Repeat("#", (bucket.Count*50+largest-1)/largest)
+		fmt.Printf("%6d-%-6d | %-50s %d\n", bucket.From, bucket.To, bar, bucket.Count)
+	}
+
+	return nil
 }
 
-type Point struct {
-	X int
-	Y int
+// parseIds parses a comma separated list of brick ids.
+func parseIds(str string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(str, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid brick id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
 
-type Brick struct {
-	Id    int
-	Start *Coordinate
-	End   *Coordinate
+// parseExtent parses the size of an image written as WxH.
+func parseExtent(str string) (image.Point, error) {
+	width, height, found := strings.Cut(strings.ToLower(str), "x")
+	w, werr := strconv.Atoi(strings.TrimSpace(width))
+	h, herr := strconv.Atoi(strings.TrimSpace(height))
+	if !found || werr != nil || herr != nil || w <= 0 || h <= 0 {
+		return image.Point{}, fmt.Errorf("invalid extent %q, expected WxH", str)
+	}
+	return image.Pt(w, h), nil
 }
 
-func (this *Brick) ToString() string {
-	return fmt.Sprintf("%d,%d,%d~%d,%d,%d", this.Start.X, this.Start.Y, this.Start.Z, this.End.X, this.End.Y, this.End.Z)
+// Helper function to create a file and let a writer function fill it.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
-func (this *Brick) MoveDown() {
-	this.Start.Z--
-	this.End.Z--
+// ExportOptions holds the stack to export and the files to export it to, empty paths are skipped.
+type ExportOptions struct {
+	Stage         string // Either "input" or "settled".
+	RemoveIds     []int  // Brick ids to remove from the settled stack before exporting.
+	ObjPath       string
+	VoxPath       string
+	SvgPath       string
+	SvgView       string
+	SvgLabels     bool
+	HeightMapPath string      // The skyline heights as a .png or .pgm image.
+	OwnersPath    string      // The skyline owners as a .png or .pgm image.
+	SkylineSize   image.Point // The size of the skyline images from X 0 and Y 0, just holding the stack when zero.
 }
 
-func (this *Brick) GetCoveredPoints() []Point {
-	var points []Point
+// exportStack writes the bricks of the configured stage to the configured files.
+// The stage is either the "input" stack or the "settled" stack, optionally after removing the given brick ids.
+func exportStack(physics brickphysics.Physics, options ExportOptions) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+
+	switch options.Stage {
+	case "input":
+		if len(options.RemoveIds) > 0 {
+			return fmt.Errorf("bricks can only be removed from the settled stack")
+		}
+	case "settled":
+		if bricks, _, _, err = physics.SimulateFall(bricks); err != nil {
+			return err
+		}
+		if len(options.RemoveIds) > 0 {
+			if bricks, _, err = physics.WhatIfRemoved(bricks, options.RemoveIds); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown stage %q, expected input or settled", options.Stage)
+	}
+
+	if options.ObjPath != "" {
+		if err := writeFile(options.ObjPath, func(w io.Writer) error { return brickphysics.WriteObj(w, bricks) }); err != nil {
+			return err
+		}
+	}
+	if options.VoxPath != "" {
+		if err := writeFile(options.VoxPath, func(w io.Writer) error { return brickphysics.WriteVox(w, bricks) }); err != nil {
+			return err
+		}
+	}
+	if options.SvgPath != "" {
+		if err := writeFile(options.SvgPath, func(w io.Writer) error { return brickphysics.WriteSvg(w, bricks, options.SvgView, options.SvgLabels) }); err != nil {
+			return err
+		}
+	}
+	if options.HeightMapPath != "" || options.OwnersPath != "" {
+		// The images start at X 0 and Y 0, so the images of different stacks line up.
+		size := options.SkylineSize
+		if size == (image.Point{}) {
+			size = brickphysics.Footprint(bricks).Max
+		}
+		skyline, err := brickphysics.NewSkylineIn(bricks, image.Rectangle{Max: size})
+		if err != nil {
+			return err
+		}
 
-	for x := this.Start.X; x <= this.End.X; x++ {
-		for y := this.Start.Y; y <= this.End.Y; y++ {
-			points = append(points, Point{X: x, Y: y})
+		heights, err := skyline.HeightImage()
+		if err != nil {
+			return err
+		}
+		if err := writeImage(options.HeightMapPath, heights, heights); err != nil {
+			return err
+		}
+		owners, err := skyline.OwnerIdImage()
+		if err != nil {
+			return err
+		}
+		if err := writeImage(options.OwnersPath, owners, skyline.OwnerImage()); err != nil {
+			return err
 		}
 	}
 
-	return points
+	return nil
 }
 
-func (this *Brick) IsSupportedBy(other *Brick) bool {
-	// Check if the upper brick is directly above the lower brick in the Z dimension
-	directlyAbove := this.Start.Z-1 == other.End.Z
+// Helper function to write an image as PGM or PNG depending on the extension, skipping an empty path.
+// PGM only holds grayscale values, so the grayscale image is used for it and the other image for PNG.
+func writeImage(path string, gray *image.Gray16, img image.Image) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case "":
+		if path == "" {
+			return nil
+		}
+	case ".pgm":
+		return writeFile(path, func(w io.Writer) error { return brickphysics.WritePgm(w, gray) })
+	case ".png":
+		return writeFile(path, func(w io.Writer) error { return png.Encode(w, img) })
+	}
+	return fmt.Errorf("unknown image format %q, expected .png or .pgm", filepath.Ext(path))
+}
 
-	if directlyAbove {
-		for _, thisPoint := range this.GetCoveredPoints() {
-			for _, otherPoint := range other.GetCoveredPoints() {
-				if thisPoint.X == otherPoint.X && thisPoint.Y == otherPoint.Y {
-					return true
-				}
-			}
+func solve(physics brickphysics.Physics, resimulate bool, workers int) (int, error) {
+	// Input bricks.
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return 0, err
+	}
+
+	// Bricks after they all found support
+	settledBricks, _, supporters, err := physics.SimulateFall(bricks)
+	if err != nil {
+		return 0, err
+	}
+
+	// Count the falls after removing 1 block at a time.
+	if resimulate {
+		var fallCounts map[int]int
+		if workers == 1 {
+			fallCounts = physics.CountFallsByResimulation(settledBricks)
+		} else {
+			fallCounts = physics.CountFallsInParallel(settledBricks, workers)
+		}
+
+		totalFallCount := 0
+		for _, fallCount := range fallCounts {
+			totalFallCount += fallCount
 		}
+		return totalFallCount, nil
 	}
 
-	return false
+	return brickphysics.ComputeChainReactions(settledBricks, brickphysics.NewSupportGraph(supporters)).Total(), nil
 }
 
-func simulateFall(bricks []Brick) ([]Brick, int) {
-	// Order the settled brick by their Z axis.
-	sort.Slice(bricks, func(i, j int) bool {
-		return bricks[i].Start.Z < bricks[j].Start.Z
-	})
+// recordFallEvents writes every move of the settling input stack to the file as JSON Lines.
+// With brick ids to remove it records the moves of that what-if simulation on the settled stack instead.
+func recordFallEvents(physics brickphysics.Physics, path string, removeIds []int) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, func(w io.Writer) error {
+		recorder := brickphysics.NewJSONLinesRecorder(w)
 
-	fallenBricks := make(map[int]int)
-
-	for i := 0; i < len(bricks); i++ {
-		// Only check for support if it's not already on the floor.
-		for bricks[i].Start.Z > 1 {
-			supported := false
-
-			// Check if the brick has found support at this position.
-			for j := 0; j < len(bricks); j++ {
-				if i != j {
-					if bricks[i].IsSupportedBy(&bricks[j]) {
-						supported = true
-						break
-					}
-				}
+		if len(removeIds) > 0 {
+			settledBricks, _, _, err := physics.SimulateFall(bricks)
+			if err != nil {
+				return err
 			}
-			if supported {
-				break
+			physics.Recorder = recorder
+			if _, _, err := physics.WhatIfRemoved(settledBricks, removeIds); err != nil {
+				return err
 			}
+		} else {
+			physics.Recorder = recorder
+			if _, _, _, err := physics.SimulateFall(bricks); err != nil {
+				return err
+			}
+		}
+
+		return recorder.Err()
+	})
+}
+
+// streamBricks drops the bricks read from the reader onto a stack as they arrive, one per line,
+// and prints where each one landed and how many bricks of the stack can be removed safely.
+func streamBricks(physics brickphysics.Physics, reader io.Reader) error {
+	stack := physics.NewStack()
+
+	nextID := 1
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		brick, err := brickphysics.ParseBrick(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("brick %d: %w", nextID, err)
+		}
+		brick.Id = nextID
+		nextID++
 
-			bricks[i].MoveDown()
-			fallenBricks[bricks[i].Id]++
+		restZ, supporters, err := stack.Drop(brick)
+		if err != nil {
+			return err
 		}
+		bricks := stack.Bricks()
+		safeCount := brickphysics.CountSafelyRemovableBricks(bricks, stack.Graph())
+		fmt.Printf("brick %d landed at Z %d on %v, %d of %d bricks can be removed safely\n", brick.Id, restZ, supporters, safeCount, len(bricks))
 	}
 
-	return bricks, len(fallenBricks)
+	return scanner.Err()
 }
 
-func solve() int {
-	// Input bricks.
-	bricks := parseInput()
+func main() {
+	resimulate := flag.Bool("resimulate", false, "count the chain reactions by re-simulating every removal")
+	workers := flag.Int("workers", 1, "the number of goroutines for -resimulate, -report and -earthquake, 0 uses one per CPU")
+	remove := flag.String("remove", "", "comma separated brick ids to remove at once, printing the bricks that fall")
+	objPath := flag.String("obj", "", "export the stack as a Wavefront OBJ file")
+	voxPath := flag.String("vox", "", "export the stack as a MagicaVoxel VOX file")
+	svgPath := flag.String("svg", "", "render the stack as an SVG image")
+	svgView := flag.String("view", "x", "the projection of the SVG image: x, y or z (top down)")
+	svgLabels := flag.Bool("labels", false, "label the bricks in the SVG image with their id")
+	heightMapPath := flag.String("heightmap", "", "export the highest Z of every point seen from above as a .png or .pgm image")
+	ownersPath := flag.String("owners", "", "export the brick owning the top of every point seen from above as a .png or .pgm image")
+	extent := flag.String("extent", "", "the size of the -heightmap and -owners images from X 0 and Y 0 as WxH, just holding the stack when empty")
+	stage := flag.String("stage", "settled", "the stack to export: input or settled, the settled stack after -remove when given")
+	stream := flag.Bool("stream", false, "drop the bricks read from stdin onto the stack one at a time as they arrive, in order of their distance to the floor")
+	eventsPath := flag.String("events", "", "write every move of the settling stack, or of the -remove what-if, as JSON Lines")
+	loads := flag.Bool("loads", false, "print the load every brick bears, after -remove when given")
+	capacity := flag.Float64("capacity", 0, "flag the bricks bearing more than this load with -loads, 0 flags none")
+	interactive := flag.Bool("interactive", false, "explore the settled stack with commands read from stdin")
+	reportPath := flag.String("report", "", "write the criticality of every brick to a .csv or .json file")
+	earthquake := flag.Int("earthquake", 0, "remove this many random bricks at once in every trial, printing the distribution of the collapses")
+	trials := flag.Int("trials", 1000, "the number of -earthquake trials")
+	seed := flag.Int64("seed", 1, "the seed picking the -earthquake bricks")
+	gravity := flag.String("gravity", "-z", "the direction the bricks fall in: -z, +z, -x, +x, -y or +y")
+	floor := flag.Int("floor", brickphysics.DefaultFloor, "the level along the gravity axis the bricks come to rest at")
+	flag.Parse()
+
+	direction, err := brickphysics.ParseGravity(*gravity)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	physics := brickphysics.Physics{Floor: *floor, Gravity: direction}
 
-	// Bricks after they all found support
-	settledBricks, _ := simulateFall(bricks)
+	if *interactive {
+		if err := runRepl(physics, os.Stdin, os.Stdout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Count the falls after removing 1 block at a time.
-	totalFallCount := 0
+	if *stream {
+		if err := streamBricks(physics, os.Stdin); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	for i := 0; i < len(settledBricks); i++ {
-		// Copy the settled bricks and remove 1.
-		bricksCopy := make([]Brick, len(settledBricks))
-		copy(bricksCopy, settledBricks)
-		bricksCopy = append(bricksCopy[:i], bricksCopy[i+1:]...)
+	var removeIds []int
+	if *remove != "" {
+		ids, err := parseIds(*remove)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		removeIds = ids
+	}
 
-		// Calculate the bricks that have fallen this simulation.
-		_, fallCount := simulateFall(bricksCopy)
-		totalFallCount += fallCount
+	if *eventsPath != "" {
+		if err := recordFallEvents(physics, *eventsPath, removeIds); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	return totalFallCount
-}
+	if *objPath != "" || *voxPath != "" || *svgPath != "" || *heightMapPath != "" || *ownersPath != "" {
+		var skylineSize image.Point
+		if *extent != "" {
+			if skylineSize, err = parseExtent(*extent); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		options := ExportOptions{
+			Stage:         *stage,
+			RemoveIds:     removeIds,
+			ObjPath:       *objPath,
+			VoxPath:       *voxPath,
+			SvgPath:       *svgPath,
+			SvgView:       *svgView,
+			SvgLabels:     *svgLabels,
+			HeightMapPath: *heightMapPath,
+			OwnersPath:    *ownersPath,
+			SkylineSize:   skylineSize,
+		}
+		if err := exportStack(physics, options); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *earthquake > 0 {
+		config := brickphysics.EarthquakeConfig{Seed: *seed, Removed: *earthquake, Trials: *trials, Workers: *workers}
+		if err := printEarthquakes(physics, config); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *reportPath != "" {
+		if err := writeCriticalityReport(physics, *reportPath, *workers); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *loads {
+		if err := printLoads(physics, removeIds, *capacity); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(removeIds) > 0 {
+		if err := printWhatIfRemoved(physics, removeIds); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-func main() {
 	startTime := time.Now()
-	solution := solve()
+	solution, err := solve(physics, *resimulate, *workers)
 	elapsedTime := time.Since(startTime)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
 
 	fmt.Printf("The solution is %d\n", solution)
 	fmt.Printf("Execution time: %s\n", elapsedTime)
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DirkHeijnen/advent-of-code-2023/day_22_brickphysics"
+)
+
+// replState is one version of the stack in the interactive mode, every removal adds a new one.
+type replState struct {
+	bricks         []brickphysics.Brick
+	index          *brickphysics.OccupancyIndex
+	graph          *brickphysics.SupportGraph
+	chainReactions *brickphysics.ChainReactions
+}
+
+// Helper function to settle the bricks and collect everything the commands ask about.
+func newReplState(physics brickphysics.Physics, bricks []brickphysics.Brick) (replState, error) {
+	physics.Index = brickphysics.NewOccupancyIndex(nil)
+	settledBricks, _, supporters, err := physics.SimulateFall(bricks)
+	if err != nil {
+		return replState{}, err
+	}
+	graph := brickphysics.NewSupportGraph(supporters)
+
+	return replState{
+		bricks:         settledBricks,
+		index:          physics.Index,
+		graph:          graph,
+		chainReactions: brickphysics.ComputeChainReactions(settledBricks, graph),
+	}, nil
+}
+
+const replHelp = `Commands:
+  remove <id>     remove a brick and let the stack settle
+  undo            put back the last removed brick
+  show x|y|top    draw the stack from the front, the side or above
+  level <z>       list the bricks at a level
+  supports <id>   list the bricks a brick rests on and the bricks resting on it
+  falls <id>      list the bricks that fall when a brick is removed, without removing it
+  help            show this help
+  quit            leave`
+
+// runRepl reads commands from the reader to explore the settled stack, writing the answers to the writer.
+func runRepl(physics brickphysics.Physics, reader io.Reader, writer io.Writer) error {
+	bricks, err := loadBricks(physics)
+	if err != nil {
+		return err
+	}
+
+	initial, err := newReplState(physics, bricks)
+	if err != nil {
+		return err
+	}
+	history := []replState{initial}
+	fmt.Fprintf(writer, "Loaded %d settled bricks, type help for the commands\n", len(bricks))
+
+	scanner := bufio.NewScanner(reader)
+	for fmt.Fprint(writer, "> "); scanner.Scan(); fmt.Fprint(writer, "> ") {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		command, args := fields[0], fields[1:]
+		if command == "quit" || command == "exit" {
+			return nil
+		}
+
+		state := history[len(history)-1]
+		next, err := runReplCommand(physics, state, command, args, writer)
+		if err != nil {
+			fmt.Fprintln(writer, "Error:", err)
+			continue
+		}
+
+		switch {
+		case next != nil:
+			history = append(history, *next)
+		case command == "undo" && len(history) == 1:
+			fmt.Fprintln(writer, "Error: nothing to undo")
+		case command == "undo":
+			history = history[:len(history)-1]
+			fmt.Fprintf(writer, "Back to %d bricks\n", len(history[len(history)-1].bricks))
+		}
+	}
+
+	fmt.Fprintln(writer)
+	return scanner.Err()
+}
+
+// Helper function to run a single command on the current state, returning the new state after a removal.
+func runReplCommand(physics brickphysics.Physics, state replState, command string, args []string, writer io.Writer) (*replState, error) {
+	switch command {
+	case "help":
+		fmt.Fprintln(writer, replHelp)
+
+	case "undo":
+		// The history is handled by the caller.
+
+	case "remove":
+		id, err := replBrickId(state, args)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining, fallen, err := physics.WhatIfRemoved(state.bricks, []int{id})
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(writer, "Removed brick %d, %d brick(s) fell\n", id, len(fallen))
+		for _, brick := range fallen {
+			fmt.Fprintf(writer, "  brick %d drops %d level(s), from Z %d to Z %d\n", brick.Id, brick.Distance(), brick.FromZ, brick.ToZ)
+		}
+
+		next, err := newReplState(physics, remaining)
+		if err != nil {
+			return nil, err
+		}
+		return &next, nil
+
+	case "show":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("usage: show x|y|top")
+		}
+		var err error
+		switch strings.ToLower(args[0]) {
+		case "x":
+			err = brickphysics.WriteGrid(writer, state.bricks, "X")
+		case "y":
+			err = brickphysics.WriteGrid(writer, state.bricks, "Y")
+		case "top", "z":
+			err = brickphysics.WriteTopGrid(writer, state.bricks)
+		default:
+			return nil, fmt.Errorf("unknown view %q, expected x, y or top", args[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+	case "level":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("usage: level <z>")
+		}
+		z, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %q", args[0])
+		}
+
+		ids := state.index.BricksAtLevel(z)
+		fmt.Fprintf(writer, "Level %d holds %d brick(s)\n", z, len(ids))
+		for _, id := range ids {
+			brick, _ := state.index.Brick(id)
+			fmt.Fprintf(writer, "  brick %d: %s\n", id, brick.ToString())
+		}
+
+	case "supports":
+		id, err := replBrickId(state, args)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(writer, "Brick %d rests on %s\n", id, formatIds(state.graph.SupportedBy(id), "the floor"))
+		fmt.Fprintf(writer, "Brick %d supports %s\n", id, formatIds(state.graph.Supports(id), "nothing"))
+
+	case "falls":
+		id, err := replBrickId(state, args)
+		if err != nil {
+			return nil, err
+		}
+
+		_, fallen, err := physics.WhatIfRemoved(state.bricks, []int{id})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(fallen))
+		for i, brick := range fallen {
+			ids[i] = brick.Id
+		}
+		fmt.Fprintf(writer, "Removing brick %d makes %d brick(s) fall: %s\n", id, state.chainReactions.FallCount(id), formatIds(ids, "none"))
+
+	default:
+		return nil, fmt.Errorf("unknown command %q, type help for the commands", command)
+	}
+
+	return nil, nil
+}
+
+// Helper function to read the single brick id argument of a command, checking the brick is in the stack.
+func replBrickId(state replState, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a single brick id")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid brick id %q", args[0])
+	}
+	if _, found := state.index.Brick(id); !found {
+		return 0, fmt.Errorf("no brick with id %d in the stack", id)
+	}
+	return id, nil
+}
+
+// Helper function to format a list of brick ids, or the given text when there are none.
+func formatIds(ids []int, none string) string {
+	if len(ids) == 0 {
+		return none
+	}
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		if id == brickphysics.FloorId {
+			parts[i] = "the floor"
+		} else {
+			parts[i] = "brick " + strconv.Itoa(id)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
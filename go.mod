@@ -0,0 +1,3 @@
+module github.com/DirkHeijnen/advent-of-code-2023
+
+go 1.21